@@ -0,0 +1,238 @@
+package pogo
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	contentTypeCharsetRE = regexp.MustCompile(`(?i)charset\s*=\s*([-\w.:]+)`)
+	headerContentTypeRE  = regexp.MustCompile(`Content-Type:([^"\\]*)`)
+)
+
+// charsetAliases to names of charsetTables
+var charsetAliases = map[string]string{
+	"koi8r":       "koi8-r",
+	"koi8u":       "koi8-u",
+	"cp1250":      "windows-1250",
+	"windows1250": "windows-1250",
+	"cp1251":      "windows-1251",
+	"windows1251": "windows-1251",
+	"cp1252":      "windows-1252",
+	"windows1252": "windows-1252",
+	"iso88591":    "iso-8859-1",
+	"latin1":      "iso-8859-1",
+	"iso88592":    "iso-8859-2",
+	"latin2":      "iso-8859-2",
+	"iso88595":    "iso-8859-5",
+	"iso885915":   "iso-8859-15",
+	"latin9":      "iso-8859-15",
+	"cp866":       "ibm866",
+	"ibm866":      "ibm866",
+}
+
+// charset is a single-byte charset, nil charset is UTF-8
+type charset struct {
+	name    string
+	table   *[128]rune
+	reverse map[rune]byte
+}
+
+// lookupCharset by name, reports false for unknown charsets
+//
+// UTF-8 and ASCII are known as nil charset.
+func lookupCharset(name string) (*charset, bool) {
+	key := strings.ToLower(name)
+	key = strings.NewReplacer("-", "", "_", "", " ", "").Replace(key)
+	switch key {
+	case "utf8", "usascii", "ascii":
+		return nil, true
+	}
+	canonical, ok := charsetAliases[key]
+	if !ok {
+		return nil, false
+	}
+	cs := &charset{
+		name:    canonical,
+		table:   charsetTables[canonical],
+		reverse: make(map[rune]byte, 128),
+	}
+	for i, r := range cs.table {
+		if r != utf8.RuneError {
+			cs.reverse[r] = byte(i + 0x80)
+		}
+	}
+
+	return cs, true
+}
+
+// mustLookupCharset panics if charset is unknown
+func mustLookupCharset(name string) *charset {
+	cs, ok := lookupCharset(name)
+	if !ok {
+		panic(errors.Errorf("unknown charset %q", name))
+	}
+
+	return cs
+}
+
+// charsetOfContentType returns charset declared in Content-Type
+//
+// Missed charset and CHARSET placeholder of templates are treated as UTF-8.
+// Unknown charsets (including all multibyte ones but UTF-8) are reported as
+// error, as text could not be decoded.
+func charsetOfContentType(contentType string) (*charset, error) {
+	name, ok := contentTypeCharset(contentType)
+	if !ok || name == "CHARSET" {
+		return nil, nil
+	}
+	cs, ok := lookupCharset(name)
+	if !ok {
+		return nil, errors.Errorf("unknown charset %q", name)
+	}
+
+	return cs, nil
+}
+
+// mustCharsetOfContentType panics if charset is unknown
+func mustCharsetOfContentType(contentType string) *charset {
+	cs, err := charsetOfContentType(contentType)
+	if err != nil {
+		panic(err)
+	}
+
+	return cs
+}
+
+// contentTypeCharset returns value of charset parameter of Content-Type
+//
+// Parameters are split without regexp, so it takes linear time even on
+// crafted header of mo file.
+func contentTypeCharset(contentType string) (string, bool) {
+	for _, param := range strings.Split(contentType, ";") {
+		split := strings.SplitN(param, "=", 2)
+		if len(split) == 2 && strings.EqualFold(strings.TrimSpace(split[0]), "charset") {
+			return strings.Trim(strings.TrimSpace(split[1]), `"`), true
+		}
+	}
+
+	return "", false
+}
+
+// sniffPOCharset find charset in header of raw po file
+func sniffPOCharset(data []byte) (*charset, error) {
+	sub := headerContentTypeRE.FindSubmatch(data)
+	if len(sub) != 2 {
+		return nil, nil
+	}
+
+	return charsetOfContentType(string(sub[1]))
+}
+
+// withCharset replace charset in Content-Type
+func withCharset(contentType, name string) string {
+	if contentTypeCharsetRE.MatchString(contentType) {
+		return contentTypeCharsetRE.ReplaceAllLiteralString(contentType, "charset="+name)
+	}
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	return contentType + "; charset=" + name
+}
+
+// String returns name of charset
+func (cs *charset) String() string {
+	if cs == nil {
+		return "utf-8"
+	}
+
+	return cs.name
+}
+
+// decode text from charset to UTF-8
+func (cs *charset) decode(text string) string {
+	if cs == nil {
+		return text
+	}
+	i := 0
+	for i < len(text) && text[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(text) {
+		return text
+	}
+	res := &strings.Builder{}
+	res.Grow(len(text) + len(text)/2)
+	res.WriteString(text[:i])
+	for ; i < len(text); i++ {
+		if c := text[i]; c < utf8.RuneSelf {
+			res.WriteByte(c)
+		} else {
+			res.WriteRune(cs.table[c-0x80])
+		}
+	}
+
+	return res.String()
+}
+
+// encode text from UTF-8 to charset
+func (cs *charset) encode(text string) (string, error) {
+	if cs == nil {
+		return text, nil
+	}
+	res := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			res = append(res, byte(r))
+			continue
+		}
+		c, ok := cs.reverse[r]
+		if !ok {
+			return "", errors.Errorf("character %q could not be encoded in %s", r, cs.name)
+		}
+		res = append(res, c)
+	}
+
+	return string(res), nil
+}
+
+// charsetWriter encodes UTF-8 stream to charset
+type charsetWriter struct {
+	w       io.Writer
+	cs      *charset
+	pending []byte
+}
+
+// Write implements io.Writer
+//
+// Incomplete rune at the end of p is kept until the next call.
+func (cw *charsetWriter) Write(p []byte) (int, error) {
+	if cw.cs == nil {
+		return cw.w.Write(p)
+	}
+	data := append(cw.pending, p...)
+	n := len(data)
+	for i := n - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				n = i
+			}
+			break
+		}
+	}
+	encoded, err := cw.cs.encode(string(data[:n]))
+	if err != nil {
+		return 0, err
+	}
+	cw.pending = append([]byte(nil), data[n:]...)
+	if _, err := io.WriteString(cw.w, encoded); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,160 @@
+package pogo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestPOFileCharset(t *testing.T) {
+	t.Parallel()
+
+	source := strings.Join([]string{
+		`msgid ""`,
+		`msgstr ""`,
+		`"Language: ru\n"`,
+		`"Content-Type: text/plain; charset=KOI8-R\n"`,
+		``,
+		`msgid "Hello"`,
+		"msgstr \"\xf0\xd2\xc9\xd7\xc5\xd4\"",
+		``,
+	}, "\n")
+
+	po, err := pogo.ReadPOFile(strings.NewReader(source))
+	require.NoError(t, err)
+	require.Len(t, po.Entries, 1)
+	assert.Equal(t, "Привет", po.Entries[0].MsgStr)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, po.Print(buf))
+	assert.Contains(t, buf.String(), "msgstr \"\xf0\xd2\xc9\xd7\xc5\xd4\"")
+
+	buf.Reset()
+	require.NoError(t, po.Print(buf, pogo.WithPrintCharset("CP1251")))
+	assert.Contains(t, buf.String(), `charset=CP1251\n`)
+	assert.Contains(t, buf.String(), "msgstr \"\xcf\xf0\xe8\xe2\xe5\xf2\"")
+
+	po, err = pogo.ReadPOFile(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "Привет", po.Entries[0].MsgStr)
+
+	assert.EqualError(t, po.Print(&bytes.Buffer{}, pogo.WithPrintCharset("latin1")),
+		`character 'П' could not be encoded in iso-8859-1`)
+	assert.EqualError(t, po.Print(&bytes.Buffer{}, pogo.WithPrintCharset("unknown")),
+		`unknown charset "unknown"`)
+}
+
+func TestPOFileCharsetOverride(t *testing.T) {
+	t.Parallel()
+
+	source := "msgid \"Hello\"\nmsgstr \"\xcf\xf0\xe8\xe2\xe5\xf2\"\n"
+	po, err := pogo.ReadPOFile(strings.NewReader(source), pogo.WithCharset("windows-1251"))
+	require.NoError(t, err)
+	assert.Equal(t, "Привет", po.Entries[0].MsgStr)
+
+	_, err = pogo.ReadPOFile(strings.NewReader(source), pogo.WithCharset("unknown"))
+	assert.EqualError(t, err, `unknown charset "unknown"`)
+}
+
+func TestMOFileCharset(t *testing.T) {
+	t.Parallel()
+
+	mo := &pogo.MOFile{
+		Header: pogo.Header{
+			Language:    "ru",
+			ContentType: "text/plain; charset=UTF-8",
+		},
+		Entries: map[string][]string{
+			"Hello": {"Привет"},
+		},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, mo.Write(buf, pogo.WithWriteCharset("cp1251")))
+	assert.Contains(t, buf.String(), "\xcf\xf0\xe8\xe2\xe5\xf2\x00")
+	assert.Contains(t, buf.String(), "charset=cp1251")
+
+	res, err := pogo.ReadMOFile(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "Привет", res.Get("Hello"))
+	assert.Equal(t, "text/plain; charset=cp1251", res.Header.ContentType)
+	assert.Equal(t, "text/plain; charset=UTF-8", mo.Header.ContentType)
+
+	assert.EqualError(t, mo.Write(&bytes.Buffer{}, pogo.WithWriteCharset("ibm-unknown")),
+		`unknown charset "ibm-unknown"`)
+}
+
+func TestPOFileDeclaredCharset(t *testing.T) {
+	t.Parallel()
+
+	header := func(lead, charset string) string {
+		return lead + strings.Join([]string{
+			`msgid ""`,
+			`msgstr ""`,
+			`"Content-Type: text/plain; charset=` + charset + `\n"`,
+			``,
+			`msgid "Hello"`,
+			`msgstr "Привет"`,
+			``,
+		}, "\n")
+	}
+	long := "# " + strings.Repeat("x", 4096) + "\n"
+
+	cases := [...]struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"template", header("", "CHARSET"), ""},
+		{"unknown", header("", "X-UNKNOWN"), `unknown charset "X-UNKNOWN"`},
+		{"multibyte", header("", "EUC-JP"), `unknown charset "EUC-JP"`},
+		{"utf-8 beyond limit", header(long, "UTF-8"), ""},
+		{"koi8-r beyond limit", header(long, "KOI8-R"),
+			"charset koi8-r of header is not declared in the first 4096 bytes of file"},
+		{"unknown beyond limit", header(long, "EUC-JP"), `unknown charset "EUC-JP"`},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			po, err := pogo.ReadPOFile(strings.NewReader(c.source))
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Привет", po.Entries[0].MsgStr)
+		})
+	}
+}
+
+func TestMOFileDeclaredCharset(t *testing.T) {
+	t.Parallel()
+
+	mo := &pogo.MOFile{
+		Header:  pogo.Header{ContentType: "text/plain; charset=UTF-8"},
+		Entries: map[string][]string{"Hello": {"Привет"}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, mo.Write(buf, pogo.WithWriteCharset("cp1251")))
+	// charset of the same length keeps offsets of file
+	data := bytes.Replace(buf.Bytes(), []byte("charset=cp1251"), []byte("charset=EUC-JP"), 1)
+
+	_, err := pogo.ReadMOFile(bytes.NewReader(data))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown charset "EUC-JP"`)
+	_, err = pogo.OpenMOLocaleBytes(data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown charset "EUC-JP"`)
+	_, err = pogo.OpenMOLocale(bytes.NewReader(data))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown charset "EUC-JP"`)
+
+	mo.Header.ContentType = "text/plain; charset=EUC-JP"
+	assert.EqualError(t, mo.Write(&bytes.Buffer{}), `unknown charset "EUC-JP"`)
+	assert.EqualError(t, (&pogo.POFile{Header: mo.Header}).Print(&bytes.Buffer{}), `unknown charset "EUC-JP"`)
+}
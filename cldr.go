@@ -0,0 +1,29 @@
+package pogo
+
+import (
+	"math"
+)
+
+// pluralCategory of number n in language
+//
+// Category is derived from gettext rules of pluralFormsTable. Only integer
+// numbers are categorized, fractions are always "other". Languages missed in
+// table use english rule.
+func pluralCategory(lang string, n float64) string {
+	if n != math.Trunc(n) {
+		return "other"
+	}
+	forms, ok := lookupPluralForms(lang)
+	if !ok {
+		forms = pluralFormsTable["en"]
+	}
+	if n < 0 {
+		n = -n
+	}
+	if n > math.MaxInt32 {
+		// rules depend on the last digits only
+		n = math.Mod(n, 1e6) + 1e6
+	}
+
+	return forms.Category(int(n))
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/vporoshok/pogo"
+)
+
+const (
+	languagePlaceholder = "{{ language }}"
+	domainPlaceholder   = "{{ domain }}"
+	extPlaceholder      = "{{ ext }}"
+)
+
+var placeholderRE = regexp.MustCompile(regexp.QuoteMeta(languagePlaceholder) + "|" +
+	regexp.QuoteMeta(domainPlaceholder) + "|" +
+	regexp.QuoteMeta(extPlaceholder))
+
+type compileConfig struct {
+	Output              string
+	Pattern             string
+	OutputPattern       string
+	UseFuzzy            bool
+	IncludeUntranslated bool
+	IncludeObsolete     bool
+	Check               bool
+	Endianness          string
+}
+
+func newCompileConfig(cmd *kingpin.CmdClause) *compileConfig {
+	cfg := new(compileConfig)
+	cmd.Flag("output", "MO-file to write result (only for one PO-file)").
+		Short('o').StringVar(&cfg.Output)
+	cmd.Flag("pattern", "Pattern of PO-files tree, as './locales/{{ language }}/{{ domain }}.{{ ext }}'").
+		StringVar(&cfg.Pattern)
+	cmd.Flag("output-pattern", "Pattern of MO-files (same as --pattern by default)").
+		StringVar(&cfg.OutputPattern)
+	cmd.Flag("use-fuzzy", "Include fuzzy entries").
+		Short('f').BoolVar(&cfg.UseFuzzy)
+	cmd.Flag("include-untranslated", "Include entries with empty translation").
+		BoolVar(&cfg.IncludeUntranslated)
+	cmd.Flag("include-obsolete", "Include obsolete entries").
+		BoolVar(&cfg.IncludeObsolete)
+	cmd.Flag("check", "Check format strings and plural forms").
+		Short('c').BoolVar(&cfg.Check)
+	cmd.Flag("endianness", "Byte order of MO-files").
+		Default("little").EnumVar(&cfg.Endianness, "little", "big")
+
+	return cfg
+}
+
+func (cfg compileConfig) moOptions() []pogo.MOOption {
+	var opts []pogo.MOOption
+	if cfg.UseFuzzy {
+		opts = append(opts, pogo.WithFuzzyEntries())
+	}
+	if cfg.IncludeUntranslated {
+		opts = append(opts, pogo.WithUntranslatedEntries())
+	}
+	if cfg.IncludeObsolete {
+		opts = append(opts, pogo.WithObsoleteEntries())
+	}
+
+	return opts
+}
+
+func (cfg compileConfig) writeOptions() []pogo.WriteOption {
+	if cfg.Endianness == "big" {
+		return []pogo.WriteOption{pogo.WithByteOrder(binary.BigEndian)}
+	}
+
+	return nil
+}
+
+type compileSource struct {
+	file, language, domain string
+}
+
+// actionCompile compile PO-files to MO-files
+//
+// Sources are set by files list or by pattern. Language and domain of source
+// file are extracted from pattern. For listed files language is taken from
+// header and domain is a base name of file.
+func actionCompile(cfg compileConfig, files []string) {
+	sources := compileSources(cfg, files)
+	if cfg.OutputPattern == "" {
+		cfg.OutputPattern = cfg.Pattern
+		if cfg.Output == "" && cfg.Pattern != "" && !strings.Contains(cfg.Pattern, extPlaceholder) {
+			app.Fatalf("pattern without %s couldn't be used as output pattern, set --output-pattern", extPlaceholder)
+		}
+	}
+	if cfg.Output != "" && len(sources) > 1 {
+		app.Fatalf("output file could be set only for one PO-file")
+	}
+	if cfg.Output == "" && cfg.OutputPattern == "" {
+		app.Fatalf("output file or output pattern should be set")
+	}
+
+	failed := false
+	for _, src := range sources {
+		po := readPOFile(src.file)
+		if src.language == "" {
+			src.language = po.Language
+		}
+		if src.language == "" {
+			src.language = filepath.Base(filepath.Dir(src.file))
+		}
+		if cfg.Check {
+			if errs := checkPOFile(po); len(errs) > 0 {
+				for _, err := range errs {
+					printCheckError(src.file, err)
+				}
+				failed = true
+				continue
+			}
+		}
+		output := cfg.Output
+		if output == "" {
+			output = expandPattern(cfg.OutputPattern, src.language, src.domain, "mo")
+		}
+		if samePath(output, src.file) {
+			app.Fatalf("output file %q overwrites source file", output)
+		}
+		writeMOFile(output, po.MO(cfg.moOptions()...), cfg.writeOptions()...)
+	}
+	if failed {
+		app.Fatalf("check failed")
+	}
+}
+
+func compileSources(cfg compileConfig, files []string) []compileSource {
+	var sources []compileSource
+	if len(files) == 0 {
+		if cfg.Pattern == "" {
+			app.Fatalf("PO-files or pattern should be set")
+		}
+		return matchPattern(cfg.Pattern)
+	}
+	for _, pattern := range files {
+		matches, err := filepath.Glob(pattern)
+		app.FatalIfError(err, "invalid pattern %q", pattern)
+		if len(matches) == 0 {
+			app.Fatalf("no files matched %q", pattern)
+		}
+		for _, file := range matches {
+			domain := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			sources = append(sources, compileSource{file: file, domain: domain})
+		}
+	}
+
+	return sources
+}
+
+// matchPattern find all PO-files matched pattern
+func matchPattern(pattern string) []compileSource {
+	var (
+		groups []string
+		expr   strings.Builder
+	)
+	// glob returns cleaned paths
+	pattern = filepath.Clean(pattern)
+	expr.WriteString("^")
+	glob := expandPattern(pattern, "*", "*", "po")
+	last := 0
+	for _, loc := range placeholderRE.FindAllStringIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		placeholder := pattern[loc[0]:loc[1]]
+		if placeholder == extPlaceholder {
+			expr.WriteString("po")
+		} else {
+			expr.WriteString(`([^/\\]+)`)
+			groups = append(groups, placeholder)
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	app.FatalIfError(err, "invalid pattern %q", pattern)
+
+	matches, err := filepath.Glob(glob)
+	app.FatalIfError(err, "invalid pattern %q", pattern)
+	sort.Strings(matches)
+	sources := make([]compileSource, 0, len(matches))
+	for _, file := range matches {
+		sub := re.FindStringSubmatch(file)
+		if sub == nil {
+			continue
+		}
+		src := compileSource{file: file}
+		for i, placeholder := range groups {
+			switch placeholder {
+			case languagePlaceholder:
+				src.language = sub[i+1]
+			case domainPlaceholder:
+				src.domain = sub[i+1]
+			}
+		}
+		sources = append(sources, src)
+	}
+
+	return sources
+}
+
+func expandPattern(pattern, language, domain, ext string) string {
+	return strings.NewReplacer(
+		languagePlaceholder, language,
+		domainPlaceholder, domain,
+		extPlaceholder, ext,
+	).Replace(pattern)
+}
+
+// samePath reports are paths point to the same file
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+
+	return absA == absB
+}
+
+func writeMOFile(name string, mo *pogo.MOFile, opts ...pogo.WriteOption) {
+	if dir := filepath.Dir(name); dir != "" {
+		app.FatalIfError(os.MkdirAll(dir, 0755), "fail to create directory %q", dir)
+	}
+	w, err := os.Create(name) // nolint:gosec
+	app.FatalIfError(err, "fail to create file %q", name)
+	err = mo.Write(w, opts...)
+	app.FatalIfError(err, "fail to write file %q", name)
+	app.FatalIfError(w.Close(), "fail to close file %q", name)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPattern(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"locales/ru/default.po":  "",
+		"locales/ru/admin.po":    "",
+		"locales/en/default.po":  "",
+		"locales/en/default.mo":  "",
+		"locales/en/sub/skip.po": "",
+		"locales/default.po":     "",
+		"flat/ru.po":             "",
+	})
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cases := [...]struct {
+		name    string
+		pattern string
+		sources []compileSource
+	}{
+		{
+			name:    "language and domain",
+			pattern: "locales/{{ language }}/{{ domain }}.{{ ext }}",
+			sources: []compileSource{
+				{"locales/en/default.po", "en", "default"},
+				{"locales/ru/admin.po", "ru", "admin"},
+				{"locales/ru/default.po", "ru", "default"},
+			},
+		},
+		{
+			name:    "fixed domain",
+			pattern: "locales/{{ language }}/default.po",
+			sources: []compileSource{
+				{"locales/en/default.po", "en", ""},
+				{"locales/ru/default.po", "ru", ""},
+			},
+		},
+		{
+			name:    "language only",
+			pattern: "flat/{{ language }}.{{ ext }}",
+			sources: []compileSource{
+				{"flat/ru.po", "ru", ""},
+			},
+		},
+		{
+			name:    "no matches",
+			pattern: "missed/{{ language }}.{{ ext }}",
+			sources: []compileSource{},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			sources := matchPattern(filepath.Join(dir, c.pattern))
+			for i := range sources {
+				rel, err := filepath.Rel(dir, sources[i].file)
+				require.NoError(t, err)
+				sources[i].file = filepath.ToSlash(rel)
+			}
+			assert.Equal(t, c.sources, sources)
+		})
+	}
+}
+
+func TestCompileExitStatus(t *testing.T) {
+	header := strings.Join([]string{
+		`msgid ""`, `msgstr ""`, `"Language: ru\n"`, `"Plural-Forms: nplurals=2; plural=n != 1;\n"`, ``, ``,
+	}, "\n")
+	dir := makeTree(t, map[string]string{
+		"valid/ru/default.po":   header + "msgid \"Open\"\nmsgstr \"Открыть\"\n",
+		"plural/ru/default.po":  header + "msgid \"%d file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"%d файл\"\n",
+		"format/ru/default.po":  header + "#, c-format\nmsgid \"%d file\"\nmsgstr \"%s файл\"\n",
+		"invalid/ru/default.po": header + "msgid \"Open\"\nmsgstr \"Открыть\"\nmsgstr \"Открыть\"\n",
+	})
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cases := [...]struct {
+		name  string
+		cfg   compileConfig
+		files []string
+		code  int
+	}{
+		{"valid", compileConfig{Check: true}, []string{"valid/ru/default.po"}, -1},
+		{"unchecked plural forms", compileConfig{}, []string{"plural/ru/default.po"}, -1},
+		{"plural forms", compileConfig{Check: true}, []string{"plural/ru/default.po"}, 1},
+		{"format", compileConfig{Check: true}, []string{"format/ru/default.po"}, 1},
+		{"invalid", compileConfig{}, []string{"invalid/ru/default.po"}, 1},
+		{"missed", compileConfig{}, []string{"missed/ru/default.po"}, 1},
+		{"overwrite", compileConfig{Output: "valid/ru/default.po"}, []string{"valid/ru/default.po"}, 1},
+		{"pattern without ext", compileConfig{Pattern: "valid/{{ language }}/default.po"}, nil, 1},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			cfg := c.cfg
+			if cfg.Output != "" {
+				cfg.Output = filepath.Join(dir, cfg.Output)
+			}
+			if cfg.Pattern != "" {
+				cfg.Pattern = filepath.Join(dir, cfg.Pattern)
+			}
+			files := make([]string, len(c.files))
+			for i, file := range c.files {
+				files[i] = filepath.Join(dir, file)
+			}
+			if cfg.Output == "" && cfg.Pattern == "" {
+				cfg.OutputPattern = filepath.Join(dir, "out", c.name, "{{ language }}", "{{ domain }}.{{ ext }}")
+			}
+			assert.Equal(t, c.code, exitCode(func() {
+				actionCompile(cfg, files)
+			}))
+			if c.code < 0 {
+				_, err := os.Stat(filepath.Join(dir, "out", c.name, "ru", "default.mo"))
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
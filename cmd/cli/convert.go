@@ -0,0 +1,28 @@
+package main
+
+import (
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/vporoshok/pogo"
+)
+
+type convertConfig struct {
+	Output    string
+	ToCharset string
+}
+
+func newConvertConfig(cmd *kingpin.CmdClause) *convertConfig {
+	cfg := new(convertConfig)
+	cmd.Flag("output", "File to write result (stdout by default)").
+		Short('o').Default("-").StringVar(&cfg.Output)
+	cmd.Flag("to-charset", "Charset of result, as 'UTF-8' or 'KOI8-R'").
+		Short('t').Required().StringVar(&cfg.ToCharset)
+
+	return cfg
+}
+
+// actionConvert re-encode PO-file and update charset in its header
+func actionConvert(cfg convertConfig, file string) {
+	po := readPOFile(file)
+	writePOFile(cfg.Output, po, pogo.WithPrintCharset(cfg.ToCharset))
+}
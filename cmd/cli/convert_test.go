@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"ru.po": strings.Join([]string{
+			`msgid ""`, `msgstr ""`, `"Content-Type: text/plain; charset=UTF-8\n"`, ``,
+			`msgid "Open"`, `msgstr "Открыть"`, ``,
+		}, "\n"),
+	})
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cases := [...]struct {
+		name    string
+		charset string
+		code    int
+		msgstr  string
+	}{
+		{"koi8-r", "KOI8-R", -1, "\xef\xd4\xcb\xd2\xd9\xd4\xd8"},
+		{"utf-8", "UTF-8", -1, "Открыть"},
+		{"unknown", "X-UNKNOWN", 1, ""},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			output := filepath.Join(dir, c.name+".po")
+			assert.Equal(t, c.code, exitCode(func() {
+				actionConvert(convertConfig{Output: output, ToCharset: c.charset}, filepath.Join(dir, "ru.po"))
+			}))
+			if c.code >= 0 {
+				return
+			}
+			data, err := ioutil.ReadFile(output) // nolint:gosec
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"Content-Type: text/plain; charset=`+c.charset+`\n"`)
+			assert.Contains(t, string(data), `msgstr "`+c.msgstr+`"`)
+		})
+	}
+}
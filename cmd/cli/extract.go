@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/vporoshok/pogo"
+)
+
+type extractConfig struct {
+	Output         string
+	Keyword        string
+	CommentTag     string
+	PackageName    string
+	PackageVersion string
+	BugsAddress    string
+	WithTests      bool
+}
+
+func newExtractConfig(cmd *kingpin.CmdClause) *extractConfig {
+	cfg := new(extractConfig)
+	cmd.Flag("output", "Directory to write POT-files (one per domain)").
+		Short('o').Default(".").StringVar(&cfg.Output)
+	cmd.Flag("keyword", "Name of translate function").
+		Default("Translate").StringVar(&cfg.Keyword)
+	cmd.Flag("add-comments", "Tag of comments placed before call to extract").
+		Default("TRANSLATORS:").StringVar(&cfg.CommentTag)
+	cmd.Flag("package-name", "Package name in header").StringVar(&cfg.PackageName)
+	cmd.Flag("package-version", "Package version in header").StringVar(&cfg.PackageVersion)
+	cmd.Flag("msgid-bugs-address", "Report address for msgid bugs").StringVar(&cfg.BugsAddress)
+	cmd.Flag("tests", "Extract resources from test files too").BoolVar(&cfg.WithTests)
+
+	return cfg
+}
+
+type extractedEntry struct {
+	pogo.POEntry
+
+	domain string
+	order  int
+}
+
+type extractor struct {
+	cfg     extractConfig
+	fset    *token.FileSet
+	entries map[string]*extractedEntry
+	// warnings output
+	stderr io.Writer
+}
+
+func newExtractor(cfg extractConfig) *extractor {
+	return &extractor{
+		cfg:     cfg,
+		fset:    token.NewFileSet(),
+		entries: make(map[string]*extractedEntry),
+		stderr:  os.Stderr,
+	}
+}
+
+func actionExtract(cfg extractConfig, paths []string) {
+	ex := newExtractor(cfg)
+	for _, file := range ex.collectFiles(paths) {
+		ex.processFile(file)
+	}
+	for domain, po := range ex.files() {
+		writePOFile(filepath.Join(cfg.Output, domain+".pot"), po)
+	}
+}
+
+// collectFiles expand paths to sorted list of go-files
+//
+// Path may be a file, a directory or a directory with suffix "/..." to walk
+// it recursive. Vendor, testdata and hidden directories are skipped on walk.
+func (ex *extractor) collectFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		recursive := false
+		if strings.HasSuffix(path, "...") {
+			recursive = true
+			path = filepath.Clean(strings.TrimSuffix(path, "..."))
+		}
+		info, err := os.Stat(path)
+		app.FatalIfError(err, "fail to stat %q", path)
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if name == path {
+					return nil
+				}
+				if !recursive || ex.isSkippedDir(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ex.isSourceFile(info.Name()) {
+				files = append(files, name)
+			}
+			return nil
+		})
+		app.FatalIfError(err, "fail to walk %q", path)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+func (ex *extractor) isSkippedDir(name string) bool {
+	return name == "vendor" || name == "testdata" ||
+		strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func (ex *extractor) isSourceFile(name string) bool {
+	if !strings.HasSuffix(name, ".go") {
+		return false
+	}
+	return ex.cfg.WithTests || !strings.HasSuffix(name, "_test.go")
+}
+
+func (ex *extractor) processFile(name string) {
+	file, err := parser.ParseFile(ex.fset, name, nil, parser.ParseComments)
+	app.FatalIfError(err, "fail to parse file %q", name)
+	comments := ex.indexComments(file)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpr); ok {
+			ex.processCall(call, comments)
+		}
+		return true
+	})
+}
+
+// indexComments map line number to comment group ended on this line
+func (ex *extractor) indexComments(file *ast.File) map[int]*ast.CommentGroup {
+	res := make(map[int]*ast.CommentGroup, len(file.Comments))
+	for _, group := range file.Comments {
+		res[ex.fset.Position(group.End()).Line] = group
+	}
+
+	return res
+}
+
+func (ex *extractor) processCall(call *ast.CallExpr, comments map[int]*ast.CommentGroup) {
+	if funcName(call.Fun) != ex.cfg.Keyword || len(call.Args) < 2 {
+		return
+	}
+	pos := ex.fset.Position(call.Pos())
+	msgID, ok := stringValue(call.Args[1])
+	if !ok {
+		ex.warn(pos, "message is not a string literal")
+		return
+	}
+	entry := extractedEntry{domain: "default"}
+	entry.MsgID = msgID
+	for _, arg := range call.Args[2:] {
+		if !ex.applyOption(pos, &entry, arg) {
+			return
+		}
+	}
+	if entry.MsgID == "" {
+		ex.warn(pos, "empty message is reserved for header")
+		return
+	}
+	entry.Reference = fmt.Sprintf("%s:%d", filepath.ToSlash(pos.Filename), pos.Line)
+	entry.EComment = ex.extractComment(comments[pos.Line-1])
+	ex.add(entry)
+}
+
+// applyOption to entry, returns false if call should be skipped because
+// domain, context or plural couldn't be evaluated
+func (ex *extractor) applyOption(pos token.Position, entry *extractedEntry, arg ast.Expr) bool {
+	call, ok := arg.(*ast.CallExpr)
+	if !ok {
+		return true
+	}
+	name := funcName(call.Fun)
+	switch name {
+	case "WithGoFormat":
+		entry.Flags.Add("go-format")
+		return true
+	case "WithGoTemplate":
+		entry.Flags.Add("go-template-format")
+		return true
+	case "WithDomain", "WithContext", "WithPlural":
+	default:
+		return true
+	}
+	var value string
+	if len(call.Args) > 0 {
+		value, ok = stringValue(call.Args[0])
+	}
+	if !ok {
+		ex.warn(pos, "argument of %s is not a string literal, call is skipped", name)
+		return false
+	}
+	switch name {
+	case "WithDomain":
+		entry.domain = value
+	case "WithContext":
+		entry.MsgCtxt = value
+		entry.HasMsgCtxt = true
+	case "WithPlural":
+		entry.MsgIDP = value
+	}
+
+	return true
+}
+
+func (ex *extractor) extractComment(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	text := group.Text()
+	i := strings.Index(text, ex.cfg.CommentTag)
+	if i < 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(text[i:])
+}
+
+func (ex *extractor) add(entry extractedEntry) {
+	key := entry.domain + "\x00" + entry.MsgID
+	if entry.HasMsgCtxt {
+		key = entry.domain + "\x00" + entry.MsgCtxt + "\x04" + entry.MsgID
+	}
+	prev, ok := ex.entries[key]
+	if !ok {
+		entry.order = len(ex.entries)
+		ex.entries[key] = &entry
+		return
+	}
+	prev.Reference += "\n" + entry.Reference
+	if entry.EComment != "" && !strings.Contains(prev.EComment, entry.EComment) {
+		if prev.EComment != "" {
+			prev.EComment += "\n"
+		}
+		prev.EComment += entry.EComment
+	}
+	if prev.MsgIDP == "" {
+		prev.MsgIDP = entry.MsgIDP
+	}
+	for _, flag := range entry.Flags {
+		prev.Flags.Add(flag)
+	}
+}
+
+func (ex *extractor) files() map[string]*pogo.POFile {
+	entries := make([]*extractedEntry, 0, len(ex.entries))
+	for _, entry := range ex.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].order < entries[j].order
+	})
+
+	res := make(map[string]*pogo.POFile)
+	for _, entry := range entries {
+		po, ok := res[entry.domain]
+		if !ok {
+			po = &pogo.POFile{Header: ex.header()}
+			res[entry.domain] = po
+		}
+		if entry.MsgIDP != "" {
+			entry.MsgStrP = []string{"", ""}
+		}
+		po.Entries = append(po.Entries, entry.POEntry)
+	}
+
+	return res
+}
+
+func (ex *extractor) header() pogo.Header {
+	header := pogo.Header{
+		Title:                   "SOME DESCRIPTIVE TITLE",
+		Fuzzy:                   true,
+		ProjectIDVersion:        strings.TrimSpace(ex.cfg.PackageName + " " + ex.cfg.PackageVersion),
+		ReportMsgidBugsTo:       ex.cfg.BugsAddress,
+		POTCreationDate:         time.Now().UTC().Truncate(time.Minute),
+		ContentType:             "text/plain; charset=UTF-8",
+		ContentTransferEncoding: "8bit",
+	}
+	if ex.cfg.PackageName != "" {
+		header.PackageLicense = ex.cfg.PackageName
+	}
+	// msgids are in english, so template should have two plural forms
+	header.PluralForms, _ = pogo.ParsePluralRules("nplurals=2; plural=n != 1;")
+
+	return header
+}
+
+func (ex *extractor) warn(pos token.Position, format string, args ...interface{}) {
+	fmt.Fprintf(ex.stderr, "%s: warning: %s\n", pos, fmt.Sprintf(format, args...))
+}
+
+// funcName return name of called function without receiver or package
+func funcName(expr ast.Expr) string {
+	switch te := expr.(type) {
+	case *ast.Ident:
+		return te.Name
+	case *ast.SelectorExpr:
+		return te.Sel.Name
+	}
+
+	return ""
+}
+
+// stringValue evaluate string literal or concatenation of literals
+func stringValue(expr ast.Expr) (string, bool) {
+	switch te := expr.(type) {
+	case *ast.BasicLit:
+		if te.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(te.Value)
+		return s, err == nil
+	case *ast.ParenExpr:
+		return stringValue(te.X)
+	case *ast.BinaryExpr:
+		if te.Op != token.ADD {
+			return "", false
+		}
+		x, ok := stringValue(te.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := stringValue(te.Y)
+		return x + y, ok
+	}
+
+	return "", false
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractorCollectFiles(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"a.go":          "",
+		"a_test.go":     "",
+		"readme.txt":    "",
+		"sub/b.go":      "",
+		"vendor/c.go":   "",
+		"testdata/d.go": "",
+		".hidden/e.go":  "",
+		"_skip/f.go":    "",
+	})
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cases := [...]struct {
+		name      string
+		paths     []string
+		withTests bool
+		files     []string
+	}{
+		{"directory", []string{dir}, false, []string{"a.go"}},
+		{"recursive", []string{dir + "/..."}, false, []string{"a.go", "sub/b.go"}},
+		{"tests", []string{dir + "/..."}, true, []string{"a.go", "a_test.go", "sub/b.go"}},
+		{"file", []string{filepath.Join(dir, "vendor", "c.go")}, false, []string{"vendor/c.go"}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			ex := newExtractor(extractConfig{WithTests: c.withTests})
+			var files []string
+			for _, file := range ex.collectFiles(c.paths) {
+				rel, err := filepath.Rel(dir, file)
+				require.NoError(t, err)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			assert.Equal(t, c.files, files)
+		})
+	}
+	assert.Equal(t, 1, exitCode(func() {
+		newExtractor(extractConfig{}).collectFiles([]string{filepath.Join(dir, "missed")})
+	}))
+}
+
+func TestExtractorProcessFile(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"main.go": strings.Join([]string{
+			`package main`,
+			``,
+			`func main() {`,
+			`	// TRANSLATORS: greeting`,
+			`	tr.Translate(ctx, "Hello")`,
+			`	tr.Translate(ctx, "Hel" + "lo", pogo.WithGoFormat())`,
+			`	tr.Translate(ctx, "Users", pogo.WithDomain("admin"))`,
+			`	tr.Translate(ctx, "Open", pogo.WithContext("menu"))`,
+			`	tr.Translate(ctx, "Open", pogo.WithContext(""))`,
+			`	tr.Translate(ctx, "%d file", pogo.WithPlural("%d files"))`,
+			`	tr.Translate(ctx, message)`,
+			`	tr.Translate(ctx, "Delete", pogo.WithDomain(domain))`,
+			`	tr.Translate(ctx, "Save", pogo.WithContext(menu))`,
+			`	tr.Translate(ctx, "%d tab", pogo.WithPlural(plural))`,
+			`	tr.Translate(ctx, "")`,
+			`}`,
+		}, "\n"),
+	})
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	ex := newExtractor(extractConfig{Keyword: "Translate", CommentTag: "TRANSLATORS:"})
+	warnings := new(bytes.Buffer)
+	ex.stderr = warnings
+	name := filepath.Join(dir, "main.go")
+	ex.processFile(name)
+
+	var entries []string
+	for domain, po := range ex.files() {
+		for _, entry := range po.Entries {
+			desc := domain + ": " + entry.MsgID
+			if entry.HasMsgCtxt {
+				desc += " [" + entry.MsgCtxt + "]"
+			}
+			if entry.MsgIDP != "" {
+				desc += " / " + entry.MsgIDP
+			}
+			if len(entry.Flags) > 0 {
+				desc += " #" + entry.Flags.String()
+			}
+			if entry.EComment != "" {
+				desc += " // " + entry.EComment
+			}
+			entries = append(entries, desc)
+		}
+	}
+	sort.Strings(entries)
+	assert.Equal(t, []string{
+		"admin: Users",
+		"default: %d file / %d files",
+		"default: Hello #go-format // TRANSLATORS: greeting",
+		"default: Open []",
+		"default: Open [menu]",
+	}, entries)
+	assert.Equal(t, []string{
+		name + ":11:2: warning: message is not a string literal",
+		name + ":12:2: warning: argument of WithDomain is not a string literal, call is skipped",
+		name + ":13:2: warning: argument of WithContext is not a string literal, call is skipped",
+		name + ":14:2: warning: argument of WithPlural is not a string literal, call is skipped",
+		name + ":15:2: warning: empty message is reserved for header",
+	}, strings.Split(strings.TrimSpace(warnings.String()), "\n"))
+}
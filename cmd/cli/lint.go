@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/vporoshok/pogo"
+)
+
+type lintConfig struct {
+	NoPluralForms bool
+	NoFormats     bool
+}
+
+func newLintConfig(cmd *kingpin.CmdClause) *lintConfig {
+	cfg := &lintConfig{}
+	cmd.Flag("no-plural-forms", "Do not check plural forms count").
+		BoolVar(&cfg.NoPluralForms)
+	cmd.Flag("no-formats", "Do not check format strings and templates").
+		BoolVar(&cfg.NoFormats)
+
+	return cfg
+}
+
+func actionLint(cfg lintConfig, files []string) {
+	failed := false
+	for _, pattern := range files {
+		matches, err := filepath.Glob(pattern)
+		app.FatalIfError(err, "invalid pattern %q", pattern)
+		if len(matches) == 0 {
+			app.Fatalf("no files matched %q", pattern)
+		}
+		for _, file := range matches {
+			po, errs := readPOFileLenient(file)
+			if !cfg.NoPluralForms {
+				errs = append(errs, po.Validate()...)
+			}
+			if !cfg.NoFormats {
+				errs = append(errs, po.CheckFormats()...)
+			}
+			for _, err := range errs {
+				printCheckError(file, err)
+			}
+			failed = failed || len(errs) > 0
+		}
+	}
+	if failed {
+		app.Fatalf("lint failed")
+	}
+}
+
+// readPOFileLenient skip malformed entries and returns parse errors
+func readPOFileLenient(name string) (*pogo.POFile, []error) {
+	r, err := os.Open(name) // nolint:gosec
+	app.FatalIfError(err, "fail to open file %q", name)
+	defer func() {
+		_ = r.Close()
+	}()
+	po, err := pogo.ReadPOFile(r, pogo.WithFilename(name), pogo.WithRecovery())
+	perrs, ok := err.(pogo.ParseErrors)
+	if err != nil && !ok {
+		app.FatalIfError(err, "fail to parse file")
+	}
+	errs := make([]error, len(perrs))
+	for i := range perrs {
+		errs[i] = perrs[i]
+	}
+
+	return po, errs
+}
+
+// checkPOFile validate format strings and plural forms of entries
+func checkPOFile(po *pogo.POFile) []error {
+	return append(po.Validate(), po.CheckFormats()...)
+}
+
+// printCheckError to stderr as file:line: message
+func printCheckError(file string, err error) {
+	if verr, ok := err.(*pogo.ValidationError); ok && verr.Line > 0 {
+		fmt.Fprintf(os.Stderr, "%s:%d: msgid %q: %s\n", file, verr.Line, verr.MsgID, verr.Message)
+		return
+	}
+	if _, ok := err.(*pogo.ParseError); ok {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintExitStatus(t *testing.T) {
+	header := strings.Join([]string{
+		`msgid ""`, `msgstr ""`, `"Plural-Forms: nplurals=2; plural=n != 1;\n"`, ``, ``,
+	}, "\n")
+	dir := makeTree(t, map[string]string{
+		"valid.po": header + "#, c-format\nmsgid \"%d file\"\nmsgid_plural \"%d files\"\n" +
+			"msgstr[0] \"%d файл\"\nmsgstr[1] \"%d файла\"\n",
+		"plural.po":   header + "msgid \"%d file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"%d файл\"\n",
+		"format.po":   header + "#, c-format\nmsgid \"%d file\"\nmsgstr \"%s файл\"\n",
+		"template.po": header + "#, go-template-format\nmsgid \"{{ .Name }}\"\nmsgstr \"{{ .Name }\"\n",
+		"syntax.po":   header + "msgid \"Open\"\nmsgstr \"Открыть\"\nmsgstr \"Открыть\"\n",
+	})
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cases := [...]struct {
+		name  string
+		cfg   lintConfig
+		files []string
+		code  int
+	}{
+		{"valid", lintConfig{}, []string{"valid.po"}, -1},
+		{"plural forms", lintConfig{}, []string{"plural.po"}, 1},
+		{"no plural forms", lintConfig{NoPluralForms: true}, []string{"plural.po"}, -1},
+		{"format", lintConfig{}, []string{"format.po"}, 1},
+		{"template", lintConfig{}, []string{"template.po"}, 1},
+		{"no formats", lintConfig{NoFormats: true}, []string{"format.po", "template.po"}, -1},
+		{"syntax", lintConfig{NoPluralForms: true, NoFormats: true}, []string{"syntax.po"}, 1},
+		{"glob", lintConfig{}, []string{"*.po"}, 1},
+		{"missed", lintConfig{}, []string{"missed.po"}, 1},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			files := make([]string, len(c.files))
+			for i, file := range c.files {
+				files[i] = filepath.Join(dir, file)
+			}
+			assert.Equal(t, c.code, exitCode(func() {
+				actionLint(c.cfg, files)
+			}))
+		})
+	}
+}
@@ -20,6 +20,28 @@ var (
 
 	wc      = app.Command("wc", "Count resources words and symbols")
 	wcFiles = newFileList(wc.Arg("files", "List of PO-files"))
+
+	extract      = app.Command("extract", "Extract resources from Go sources to POT-files")
+	extractCfg   = newExtractConfig(extract)
+	extractPaths = newFileList(extract.Arg("paths", "List of files or directories (use dir/... to walk recursive)").
+			Default("./..."))
+
+	merge      = app.Command("merge", "Merge PO-files with POT-file (like msgmerge)")
+	mergeCfg   = newMergeConfig(merge)
+	mergeFiles = newFileList(merge.Arg("files", "PO-files with translations and POT-file with actual resources").
+			Required())
+
+	compile      = app.Command("compile", "Compile PO-files to MO-files (like msgfmt)")
+	compileCfg   = newCompileConfig(compile)
+	compileFiles = newFileList(compile.Arg("files", "List of PO-files"))
+
+	lint      = app.Command("lint", "Check plural forms and format strings of PO-files")
+	lintCfg   = newLintConfig(lint)
+	lintFiles = newFileList(lint.Arg("files", "List of PO-files").Required())
+
+	convert     = app.Command("convert", "Convert PO-file to another charset (like msgconv)")
+	convertCfg  = newConvertConfig(convert)
+	convertFile = convert.Arg("file", "PO-file to convert").Required().String()
 )
 
 type fileList []string
@@ -44,10 +66,19 @@ func newFileList(s kingpin.Settings) (target *[]string) {
 }
 
 func main() {
-	// nolint:gocritic
 	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
 	case wc.FullCommand():
 		actionWC(*wcFiles)
+	case extract.FullCommand():
+		actionExtract(*extractCfg, *extractPaths)
+	case merge.FullCommand():
+		actionMerge(*mergeCfg, *mergeFiles)
+	case compile.FullCommand():
+		actionCompile(*compileCfg, *compileFiles)
+	case lint.FullCommand():
+		actionLint(*lintCfg, *lintFiles)
+	case convert.FullCommand():
+		actionConvert(*convertCfg, *convertFile)
 	}
 }
 
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type exitStatus int
+
+// exitCode run fn and returns status passed to app terminate, -1 if fn
+// returns normally
+func exitCode(fn func()) (code int) {
+	app.Terminate(func(code int) {
+		panic(exitStatus(code))
+	})
+	app.ErrorWriter(ioutil.Discard)
+	defer func() {
+		app.Terminate(os.Exit)
+		app.ErrorWriter(os.Stderr)
+		if r := recover(); r != nil {
+			status, ok := r.(exitStatus)
+			if !ok {
+				panic(r)
+			}
+			code = int(status)
+		}
+	}()
+	fn()
+
+	return -1
+}
+
+// makeTree create files in temporary directory and returns its path
+func makeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "pogo")
+	require.NoError(t, err)
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, ioutil.WriteFile(name, []byte(data), 0644))
+	}
+
+	return dir
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/vporoshok/pogo"
+)
+
+type mergeConfig struct {
+	Output          string
+	Update          bool
+	Similarity      float64
+	NoFuzzyMatching bool
+	NoObsolete      bool
+}
+
+func newMergeConfig(cmd *kingpin.CmdClause) *mergeConfig {
+	cfg := new(mergeConfig)
+	cmd.Flag("output", "File to write result (stdout by default)").
+		Short('o').Default("-").StringVar(&cfg.Output)
+	cmd.Flag("update", "Update PO-files in place").
+		Short('U').BoolVar(&cfg.Update)
+	cmd.Flag("similarity", "Minimal similarity of messages to fuzzy match").
+		Default("0.8").Float64Var(&cfg.Similarity)
+	cmd.Flag("no-fuzzy-matching", "Do not use fuzzy matching").
+		Short('N').BoolVar(&cfg.NoFuzzyMatching)
+	cmd.Flag("no-obsolete", "Drop entries missed in POT-file instead of mark them obsolete").
+		BoolVar(&cfg.NoObsolete)
+
+	return cfg
+}
+
+// actionMerge merge PO-files with POT-file
+//
+// Last of files is a POT-file with actual resources, all others are PO-files
+// with translations. Without update flag only one PO-file is permitted.
+// File names may be a glob patterns, as 'locales/*/domain.po'.
+func actionMerge(cfg mergeConfig, files []string) {
+	if len(files) < 2 {
+		app.Fatalf("expected PO-file and POT-file")
+	}
+	ref := readPOFile(files[len(files)-1])
+	var defs []string
+	for _, pattern := range files[:len(files)-1] {
+		matches, err := filepath.Glob(pattern)
+		app.FatalIfError(err, "invalid pattern %q", pattern)
+		if len(matches) == 0 {
+			app.Fatalf("no files matched %q", pattern)
+		}
+		defs = append(defs, matches...)
+	}
+	if !cfg.Update && len(defs) > 1 {
+		app.Fatalf("multiple PO-files could be merged only with --update flag")
+	}
+	opts := []pogo.UpdateOption{
+		pogo.WithSimilarity(cfg.Similarity),
+		pogo.WithFuzzyMatching(!cfg.NoFuzzyMatching),
+		pogo.WithObsolete(!cfg.NoObsolete),
+	}
+	for _, def := range defs {
+		res := mergePOFiles(readPOFile(def), ref, opts...)
+		output := cfg.Output
+		if cfg.Update {
+			output = def
+		}
+		writePOFile(output, res)
+	}
+}
+
+// mergePOFiles like msgmerge do it
+//
+// Header is taken from translations with creation date of template. Plural
+// entries of template are extended to count of plural forms of translations.
+func mergePOFiles(def, ref *pogo.POFile, opts ...pogo.UpdateOption) *pogo.POFile {
+	res := def.Update(ref, opts...)
+	res.Header = def.Header
+	res.POTCreationDate = ref.POTCreationDate
+	n := res.PluralForms.Len()
+	for i := range res.Entries {
+		entry := &res.Entries[i]
+		if entry.MsgIDP == "" || len(entry.MsgStrP) == n {
+			continue
+		}
+		forms := make([]string, n)
+		if len(entry.MsgStrP) == 0 {
+			forms[0] = entry.MsgStr
+		}
+		copy(forms, entry.MsgStrP)
+		entry.MsgStr, entry.MsgStrP = "", forms
+	}
+
+	return res
+}
+
+func readPOFile(name string) *pogo.POFile {
+	r, err := os.Open(name) // nolint:gosec
+	app.FatalIfError(err, "fail to open file %q", name)
+	defer func() {
+		_ = r.Close()
+	}()
+	po, err := pogo.ReadPOFile(r, pogo.WithFilename(name))
+	app.FatalIfError(err, "fail to parse file")
+
+	return po
+}
+
+func writePOFile(name string, po *pogo.POFile, opts ...pogo.PrintOption) {
+	if name == "-" {
+		app.FatalIfError(po.Print(os.Stdout, opts...), "fail to write result")
+		return
+	}
+	w, err := os.Create(name) // nolint:gosec
+	app.FatalIfError(err, "fail to create file %q", name)
+	err = po.Print(w, opts...)
+	app.FatalIfError(err, "fail to write file %q", name)
+	app.FatalIfError(w.Close(), "fail to close file %q", name)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestMergePOFiles(t *testing.T) {
+	read := func(lines ...string) *pogo.POFile {
+		po, err := pogo.ReadPOFile(bytes.NewBufferString(strings.Join(lines, "\n")))
+		require.NoError(t, err)
+		return po
+	}
+	def := read(
+		`msgid ""`, `msgstr ""`, `"Language: ru\n"`, `"POT-Creation-Date: 2019-01-01 00:00+0000\n"`,
+		`"Plural-Forms: nplurals=3; plural=n%10==1 && n%100!=11 ? 0 : `+
+			`n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2;\n"`, ``,
+		`msgid "Open file"`, `msgstr "Открыть файл"`, ``,
+		`msgid "Save the document"`, `msgstr "Сохранить документ"`, ``,
+		`msgid "Quit"`, `msgstr "Выход"`, ``,
+		`msgid "%d file"`, `msgstr "%d файл"`, ``,
+	)
+	ref := read(
+		`msgid ""`, `msgstr ""`, `"POT-Creation-Date: 2020-02-02 00:00+0000\n"`,
+		`"Plural-Forms: nplurals=2; plural=n != 1;\n"`, ``,
+		`msgid "Open file"`, `msgstr ""`, ``,
+		`msgid "Save the documents"`, `msgstr ""`, ``,
+		`msgid "%d file"`, `msgid_plural "%d files"`, `msgstr[0] ""`, `msgstr[1] ""`, ``,
+	)
+
+	type result struct {
+		MsgID    string
+		MsgStr   string
+		MsgStrP  []string
+		Fuzzy    bool
+		Obsolete bool
+	}
+	cases := [...]struct {
+		name    string
+		opts    []pogo.UpdateOption
+		entries []result
+	}{
+		{
+			name: "default",
+			entries: []result{
+				{MsgID: "Open file", MsgStr: "Открыть файл"},
+				{MsgID: "Save the documents", MsgStr: "Сохранить документ", Fuzzy: true},
+				{MsgID: "%d file", MsgStrP: []string{"%d файл", "", ""}, Fuzzy: true},
+				{MsgID: "Quit", MsgStr: "Выход", Obsolete: true},
+			},
+		},
+		{
+			name: "no fuzzy matching",
+			opts: []pogo.UpdateOption{pogo.WithFuzzyMatching(false)},
+			entries: []result{
+				{MsgID: "Open file", MsgStr: "Открыть файл"},
+				{MsgID: "Save the documents"},
+				{MsgID: "%d file", MsgStrP: []string{"%d файл", "", ""}, Fuzzy: true},
+				{MsgID: "Save the document", MsgStr: "Сохранить документ", Obsolete: true},
+				{MsgID: "Quit", MsgStr: "Выход", Obsolete: true},
+			},
+		},
+		{
+			name: "no obsolete",
+			opts: []pogo.UpdateOption{pogo.WithObsolete(false)},
+			entries: []result{
+				{MsgID: "Open file", MsgStr: "Открыть файл"},
+				{MsgID: "Save the documents", MsgStr: "Сохранить документ", Fuzzy: true},
+				{MsgID: "%d file", MsgStrP: []string{"%d файл", "", ""}, Fuzzy: true},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			res := mergePOFiles(def, ref, c.opts...)
+			assert.Equal(t, "ru", res.Language)
+			assert.Equal(t, 3, res.PluralForms.Len())
+			assert.Equal(t, ref.POTCreationDate, res.POTCreationDate)
+			entries := make([]result, 0, len(res.Entries))
+			for _, entry := range res.Entries {
+				entries = append(entries, result{
+					MsgID:    entry.MsgID,
+					MsgStr:   entry.MsgStr,
+					MsgStrP:  entry.MsgStrP,
+					Fuzzy:    entry.Flags.Contain("fuzzy"),
+					Obsolete: entry.Obsolete,
+				})
+			}
+			assert.Equal(t, c.entries, entries)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package pogo
+
+import "time"
+
+// WithClock replace clock of translator
+func WithClock(now func() time.Time) TranslatorOption {
+	return fnTranslatorOption(func(t *Translator) {
+		t.now = now
+	})
+}
+
+// WithTicks replace ticker of translator watcher
+func WithTicks(ticks <-chan time.Time) TranslatorOption {
+	return fnTranslatorOption(func(t *Translator) {
+		t.ticker = func(time.Duration) (<-chan time.Time, func()) {
+			return ticks, func() {}
+		}
+	})
+}
+
+// PluralCategory of number n in language
+func PluralCategory(lang string, n float64) string {
+	return pluralCategory(lang, n)
+}
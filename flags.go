@@ -12,9 +12,12 @@ func (flags Flags) String() string {
 
 // Parse text to set of flags
 //
-// Removes empty and duplicates
+// Removes empty and duplicates. Flags could be separated by commas or line
+// breaks, as several "#," lines are read as one text.
 func (flags *Flags) Parse(text string) {
-	tags := strings.Split(text, ",")
+	tags := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
 	res := make(Flags, 0, len(tags))
 	exists := make(map[string]bool, len(tags))
 	for i := range tags {
@@ -31,6 +31,11 @@ func TestFlagsText(t *testing.T) {
 			source: "  foo, \nbar  ",
 			result: "foo, bar",
 		},
+		{
+			name:   "several lines",
+			source: "foo\nbar, baz",
+			result: "foo, bar, baz",
+		},
 		{
 			name:   "duplicates",
 			source: "foo, foo",
@@ -0,0 +1,256 @@
+package pogo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// maxFormatArgs is a limit of argument index in format string as NL_ARGMAX
+// of glibc
+const maxFormatArgs = 4096
+
+var (
+	cFormatRE = regexp.MustCompile(
+		`%(\d+\$)?[-+ #0']*(\*|\d+)?(\.(\*|\d+)?)?(hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspn%]`)
+	goFormatRE     = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*(\*|\d+)?(\.(\*|\d+)?)?[a-zA-Z%]`)
+	pythonFormatRE = regexp.MustCompile(`%(\([^)]*\))?[-+ #0]*(\*|\d+)?(\.(\*|\d+)?)?[hlL]?[diouxXeEfFgGcrsa%]`)
+)
+
+// formatChecks by entry flag, each returns description of placeholders
+var formatChecks = [...]struct {
+	flag         string
+	placeholders func(text string) (string, error)
+}{
+	{"c-format", cFormatPlaceholders},
+	{"go-format", goFormatPlaceholders},
+	{"python-format", pythonFormatPlaceholders},
+}
+
+// CheckFormats of entries
+//
+// Placeholders of translations are compared with placeholders of original
+// for entries flagged as c-format, go-format or python-format. Arguments
+// may be reordered by explicit indexes or names, but their verbs should be
+// the same. First plural form may follow msgid instead of msgid_plural.
+// Plural forms used for a single number only (like "one file") may omit
+// placeholders at all.
+//
+// Entries flagged as go-template-format are checked for text/template
+// syntax. Empty translations and obsolete entries are skipped.
+func (po *POFile) CheckFormats() []error {
+	var errs []error
+	single := po.singleNumberForms()
+	for i := range po.Entries {
+		entry := &po.Entries[i]
+		if entry.Obsolete {
+			continue
+		}
+		for _, check := range formatChecks {
+			if entry.Flags.Contain(check.flag) {
+				errs = append(errs, checkEntryFormat(entry, check.flag, check.placeholders, single)...)
+			}
+		}
+		if entry.Flags.Contain("go-template-format") {
+			errs = append(errs, checkEntryTemplate(entry)...)
+		}
+	}
+
+	return errs
+}
+
+// singleNumberForms returns plural forms used for only one number
+func (po *POFile) singleNumberForms() map[int]bool {
+	counts := make(map[int]int, po.PluralForms.Len())
+	for n := 0; n < pluralCheckLimit; n++ {
+		counts[po.PluralForms.Eval(n)]++
+	}
+	res := make(map[int]bool, len(counts))
+	for form, count := range counts {
+		res[form] = count == 1
+	}
+
+	return res
+}
+
+// forEachTranslation call fn with original and translation of every form
+func (entry *POEntry) forEachTranslation(fn func(form int, name, id, str string)) {
+	if len(entry.MsgStrP) == 0 {
+		fn(-1, "msgstr", entry.MsgID, entry.MsgStr)
+		return
+	}
+	for i, str := range entry.MsgStrP {
+		id := entry.MsgIDP
+		if id == "" {
+			id = entry.MsgID
+		}
+		fn(i, fmt.Sprintf("msgstr[%d]", i), id, str)
+	}
+}
+
+func checkEntryFormat(
+	entry *POEntry, flag string, placeholders func(string) (string, error), single map[int]bool,
+) []error {
+	var errs []error
+	fail := func(message string) {
+		errs = append(errs, &ValidationError{
+			Line:    entry.Line,
+			MsgID:   entry.MsgID,
+			Message: message,
+		})
+	}
+	describe := func(name, text string) (string, bool) {
+		res, err := placeholders(text)
+		if err != nil {
+			fail(fmt.Sprintf("invalid %s in %s: %s", flag, name, err))
+			return "", false
+		}
+		return res, true
+	}
+	msgID, ok := describe("msgid", entry.MsgID)
+	if !ok {
+		return errs
+	}
+	msgIDP := msgID
+	if entry.MsgIDP != "" {
+		if msgIDP, ok = describe("msgid_plural", entry.MsgIDP); !ok {
+			return errs
+		}
+	}
+	entry.forEachTranslation(func(form int, name, _, str string) {
+		if str == "" {
+			return
+		}
+		got, ok := describe(name, str)
+		if !ok {
+			return
+		}
+		expected := msgID
+		if form >= 0 {
+			expected = msgIDP
+		}
+		switch {
+		case expected == got,
+			form == 0 && msgID == got,
+			single[form] && got == "":
+			return
+		}
+		fail(fmt.Sprintf("%s of %s mismatch: expected [%s], got [%s]", flag, name, expected, got))
+	})
+
+	return errs
+}
+
+func checkEntryTemplate(entry *POEntry) []error {
+	var errs []error
+	check := func(name, text string) {
+		if _, err := template.New("").Parse(text); err != nil {
+			errs = append(errs, &ValidationError{
+				Line:    entry.Line,
+				MsgID:   entry.MsgID,
+				Message: fmt.Sprintf("invalid template in %s: %s", name, err),
+			})
+		}
+	}
+	check("msgid", entry.MsgID)
+	if entry.MsgIDP != "" {
+		check("msgid_plural", entry.MsgIDP)
+	}
+	entry.forEachTranslation(func(_ int, name, _, str string) {
+		if str != "" {
+			check(name, str)
+		}
+	})
+
+	return errs
+}
+
+// cFormatPlaceholders returns conversions ordered by argument number
+func cFormatPlaceholders(text string) (string, error) {
+	var args []string
+	next := 1
+	for _, sub := range cFormatRE.FindAllStringSubmatch(text, -1) {
+		verb := sub[0][len(sub[0])-1:]
+		if verb == "%" {
+			continue
+		}
+		if verb == "i" {
+			verb = "d"
+		}
+		k := next
+		if sub[1] != "" {
+			k, _ = strconv.Atoi(strings.TrimSuffix(sub[1], "$"))
+		}
+		next = k + 1
+		var err error
+		if args, err = setPlaceholder(args, k, sub[5]+verb); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// goFormatPlaceholders returns verbs ordered by argument index
+func goFormatPlaceholders(text string) (string, error) {
+	var args []string
+	next := 1
+	for _, sub := range goFormatRE.FindAllStringSubmatch(text, -1) {
+		verb := sub[0][len(sub[0])-1:]
+		if verb == "%" {
+			continue
+		}
+		k := next
+		if sub[1] != "" {
+			k, _ = strconv.Atoi(strings.Trim(sub[1], "[]"))
+		}
+		next = k + 1
+		var err error
+		if args, err = setPlaceholder(args, k, verb); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// pythonFormatPlaceholders returns conversions in order or sorted by name
+func pythonFormatPlaceholders(text string) (string, error) {
+	var args []string
+	for _, sub := range pythonFormatRE.FindAllStringSubmatch(text, -1) {
+		verb := sub[0][len(sub[0])-1:]
+		if verb == "%" {
+			continue
+		}
+		if verb == "i" {
+			verb = "d"
+		}
+		args = append(args, sub[1]+verb)
+	}
+	if len(args) > 0 && strings.HasPrefix(args[0], "(") {
+		sort.Strings(args)
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// setPlaceholder of k-th (one based) argument, missed arguments are marked by "?"
+func setPlaceholder(args []string, k int, verb string) ([]string, error) {
+	if k < 1 {
+		k = 1
+	}
+	if k > maxFormatArgs {
+		return args, errors.Errorf("argument index %d is out of limit %d", k, maxFormatArgs)
+	}
+	for len(args) < k {
+		args = append(args, "?")
+	}
+	args[k-1] = verb
+
+	return args, nil
+}
@@ -0,0 +1,47 @@
+package pogo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestPOFileCheckFormats(t *testing.T) {
+	t.Parallel()
+
+	po, err := pogo.ReadPOFile(bytes.NewBufferString(strings.Join([]string{
+		`msgid ""`, `msgstr ""`, `"Plural-Forms: nplurals=2; plural=n != 1;\n"`, ``,
+		`#, c-format`, `msgid "%s has %d files"`, `msgstr "%2$d файлов у %1$s"`, ``,
+		`#, c-format`, `msgid "%d file"`, `msgid_plural "%d files"`,
+		`msgstr[0] "один файл"`, `msgstr[1] "%s файлов"`, ``,
+		`#, go-format`, `msgid "Hello %s"`, `msgstr "Привет"`, ``,
+		`#, go-format`, `msgid "%s of %d"`, `msgstr "%[2]d из %[1]s (100%%)"`, ``,
+		`#, python-format`, `msgid "%(name)s has %(count)d"`, `msgstr "%(count)d у %(name)s"`, ``,
+		`#, python-format`, `msgid "%s has %d"`, `msgstr "%d у %s"`, ``,
+		`#, go-template-format`, `msgid "Hello {{ .Name }}"`, `msgstr "Привет {{ .Name }"`, ``,
+		`#, c-format`, `msgid "Untranslated %d"`, `msgstr ""`, ``,
+		`#, c-format`, `#~ msgid "Obsolete %d"`, `#~ msgstr "Устарело"`, ``,
+		`#, c-format`, `msgid "Huge %d"`, `msgstr "Огромный %999999999$d"`, ``,
+		`#, go-format`, `msgid "Huge %[99999999]d"`, `msgstr "Огромный %d"`, ``,
+	}, "\n")))
+	require.NoError(t, err)
+
+	var errs []string
+	for _, err := range po.CheckFormats() {
+		errs = append(errs, err.Error())
+	}
+	assert.Equal(t, []string{
+		`line 10: msgid "%d file": c-format of msgstr[1] mismatch: expected [d], got [s]`,
+		`line 16: msgid "Hello %s": go-format of msgstr mismatch: expected [s], got []`,
+		`line 28: msgid "%s has %d": python-format of msgstr mismatch: expected [s d], got [d s]`,
+		`line 32: msgid "Hello {{ .Name }}": invalid template in msgstr: template: :1: unexpected "}" in operand`,
+		`line 44: msgid "Huge %d": invalid c-format in msgstr: argument index 999999999 is out of limit 4096`,
+		`line 48: msgid "Huge %[99999999]d": invalid go-format in msgid: ` +
+			`argument index 99999999 is out of limit 4096`,
+	}, errs)
+}
@@ -19,6 +19,8 @@ type Formatter struct {
 
 	buffer *bytes.Buffer
 	output io.Writer
+	// open is set if raw text is written without line break at the end
+	open bool
 }
 
 // NewFormatter to write in w
@@ -38,11 +40,32 @@ func (f *Formatter) Format(text string) error {
 
 // BreakLine add \n
 func (f *Formatter) BreakLine() error {
-	_, err := io.WriteString(f.output, "\n")
-	return errors.WithStack(err)
+	return recoverHandledError(func() {
+		f.mustCloseLine()
+		f.mustWrite(f.output, "\n")
+	})
+}
+
+// mustWriteRaw write text as is, text could have no line break at the end
+func (f *Formatter) mustWriteRaw(text string) {
+	if text == "" {
+		return
+	}
+	f.mustCloseLine()
+	f.mustWrite(f.output, text)
+	f.open = !strings.HasSuffix(text, "\n")
+}
+
+// mustCloseLine break line left open by raw text
+func (f *Formatter) mustCloseLine() {
+	if f.open {
+		f.mustWrite(f.output, "\n")
+		f.open = false
+	}
 }
 
 func (f *Formatter) mustFormat(text string) {
+	f.mustCloseLine()
 	lines := f.splitLines(text)
 	if f.Prefix != "" {
 		f.mustWrite(f.output, f.Border)
@@ -110,7 +133,7 @@ func (f *Formatter) writeLine(line string) {
 }
 
 func (f *Formatter) flush() {
-	if f.buffer.Len() == 0 && f.Width > 0 {
+	if f.buffer.Len() == 0 && (f.Width > 0 || f.Prefix != "") {
 		return
 	}
 	if f.buffer.Len() == 0 && f.Prefix == "" {
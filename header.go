@@ -61,6 +61,31 @@ type Header struct {
 	ContentTransferEncoding string
 	Unknown                 [][2]string
 	PluralForms             PluralRules
+	// PluralFormsError is an error of parsing Plural-Forms, PluralForms
+	// fall back to rules of Language in this case
+	PluralFormsError error
+
+	source *headerSource
+}
+
+// headerSource is a source of header read in lossless mode
+//
+// Formatted parts of header are kept to find modified ones.
+type headerSource struct {
+	// entry is nil if source has no header
+	entry   *POEntry
+	comment string
+	msgStr  string
+	fuzzy   bool
+}
+
+func newHeaderSource(header *Header, entry *POEntry) *headerSource {
+	return &headerSource{
+		entry:   entry,
+		comment: header.getEntryComment(),
+		msgStr:  header.getEntryMsgStr(),
+		fuzzy:   header.Fuzzy,
+	}
 }
 
 // FromEntry parse entry as header
@@ -68,12 +93,25 @@ func (header *Header) FromEntry(entry *POEntry) {
 	header.parseEntryComment(entry.TComment)
 	header.Fuzzy = entry.Flags.Contain("fuzzy")
 	header.parseEntryMsgStr(entry.MsgStr)
+	header.fallbackPluralForms()
 	if header.ContentType == "" {
 		header.ContentType = "text/plain; charset=UTF-8"
 	}
 	if header.ContentTransferEncoding == "" {
 		header.ContentTransferEncoding = "8bit"
 	}
+	if entry.source != nil {
+		copied := *entry
+		header.source = newHeaderSource(header, &copied)
+	}
+}
+
+// fallbackPluralForms by language if header has no valid Plural-Forms
+func (header *Header) fallbackPluralForms() {
+	if header.PluralForms.expr != nil || header.Language == "" {
+		return
+	}
+	header.PluralForms, _ = PluralRulesForLanguage(header.Language)
 }
 
 func (header *Header) parseEntryComment(comment string) {
@@ -136,7 +174,7 @@ func (header *Header) parseKeyValue(key, val string) {
 	case "Content-Transfer-Encoding":
 		header.ContentTransferEncoding = val
 	case "Plural-Forms":
-		header.PluralForms, _ = ParsePluralRules(val)
+		header.PluralForms, header.PluralFormsError = ParsePluralRules(val)
 	default:
 		header.Unknown = append(header.Unknown, [2]string{key, val})
 	}
@@ -154,6 +192,107 @@ func (header *Header) ToEntry() POEntry {
 	return entry
 }
 
+// printEntry returns entry to print and false if there is nothing to print
+//
+// Header read in lossless mode is printed from source entry, where only
+// modified parts are replaced. Absent header is not printed until it is
+// modified.
+func (header *Header) printEntry() (POEntry, bool) {
+	src := header.source
+	if src == nil {
+		return header.ToEntry(), true
+	}
+	comment, msgStr := header.getEntryComment(), header.getEntryMsgStr()
+	if src.entry == nil {
+		if comment == src.comment && msgStr == src.msgStr && header.Fuzzy == src.fuzzy {
+			return POEntry{}, false
+		}
+		return header.ToEntry(), true
+	}
+	entry := *src.entry
+	if comment != src.comment {
+		entry.TComment = comment
+	}
+	if header.Fuzzy != src.fuzzy {
+		entry.Flags = append(Flags{}, entry.Flags...)
+		if header.Fuzzy {
+			entry.Flags.Add("fuzzy")
+		} else {
+			entry.Flags.Remove("fuzzy")
+		}
+	}
+	if msgStr != src.msgStr {
+		entry.MsgStr = patchHeaderMsgStr(entry.MsgStr, src.msgStr, msgStr)
+	}
+
+	return entry, true
+}
+
+// patchHeaderMsgStr replace in source lines of fields changed from prev to
+// next, new fields are appended to the end
+func patchHeaderMsgStr(source, prev, next string) string {
+	prevLines, _ := headerLines(prev)
+	nextLines, keys := headerLines(next)
+	res := &strings.Builder{}
+	seen := make(map[string]bool, len(keys))
+	for _, line := range strings.SplitAfter(source, "\n") {
+		key, ok := headerKey(line)
+		switch {
+		case !ok || seen[key]:
+			res.WriteString(line)
+		case prevLines[key] == nextLines[key]:
+			seen[key] = true
+			res.WriteString(line)
+		default:
+			seen[key] = true
+			res.WriteString(nextLines[key])
+		}
+	}
+	for _, key := range keys {
+		if seen[key] || prevLines[key] == nextLines[key] {
+			continue
+		}
+		if res.Len() > 0 && !strings.HasSuffix(res.String(), "\n") {
+			res.WriteString("\n")
+		}
+		res.WriteString(nextLines[key])
+	}
+
+	return res.String()
+}
+
+// headerLines split formatted header fields by keys
+func headerLines(text string) (lines map[string]string, keys []string) {
+	lines = make(map[string]string)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if key, ok := headerKey(line); ok {
+			if _, exists := lines[key]; !exists {
+				lines[key] = line
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return lines, keys
+}
+
+// headerValue of field in msgstr of header entry, empty if it is missed
+func headerValue(text, key string) string {
+	for _, line := range strings.Split(text, "\n") {
+		split := strings.SplitN(line, ":", 2)
+		if len(split) == 2 && split[0] == key {
+			return strings.TrimSpace(split[1])
+		}
+	}
+
+	return ""
+}
+
+func headerKey(line string) (string, bool) {
+	split := strings.SplitN(line, ":", 2)
+	return split[0], len(split) == 2
+}
+
 func (header *Header) getEntryComment() string {
 	res := &strings.Builder{}
 	_, _ = fmt.Fprintf(res, "%s.\n", header.Title)
@@ -80,5 +80,33 @@ func TestHeader(t *testing.T) {
 	assert.Equal(t, [][2]string{{"MIME-Version", "1.0"}}, header.Unknown)
 
 	newEntry := header.ToEntry()
+	newEntry.Line = entry.Line
 	assert.Equal(t, entry, newEntry)
 }
+
+func TestHeaderPluralFormsFallback(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name   string
+		msgstr string
+		result string
+	}{
+		{"missed", "Language: uk\n", "nplurals=3; plural=n%10==1 && n%100!=11 ? 0 : " +
+			"n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2;"},
+		{"unparsable", "Language: fr\nPlural-Forms: nplurals=2; plural=n >;\n", "nplurals=2; plural=n > 1;"},
+		{"explicit", "Language: fr\nPlural-Forms: nplurals=2; plural=n != 1;\n", "nplurals=2; plural=n != 1;"},
+		{"unknown language", "Language: xx\n", "nplurals=1; plural=0;"},
+		{"deep nesting", "Language: fr\nPlural-Forms: nplurals=2; plural=" +
+			strings.Repeat("(", 3<<20) + "n" + strings.Repeat(")", 3<<20) + ";\n", "nplurals=2; plural=n > 1;"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			header := pogo.Header{}
+			header.FromEntry(&pogo.POEntry{MsgStr: c.msgstr})
+			assert.Equal(t, c.result, header.PluralForms.String())
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package pogo
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LanguageLister is an optional interface of Loader to list available languages
+type LanguageLister interface {
+	Languages() ([]string, error)
+}
+
+// ParseAcceptLanguage parse Accept-Language header value
+//
+// Returns languages ordered by quality. Languages with zero quality and
+// wildcard are skipped. Quality out of range [0, 1] is clamped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		lang := strings.TrimSpace(params[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				q, err = strconv.ParseFloat(param[2:], 64)
+				switch {
+				case err != nil || math.IsNaN(q) || q < 0:
+					q = 0
+				case q > 1:
+					q = 1
+				}
+			}
+		}
+		if q > 0 {
+			langs = append(langs, weighted{lang, q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+	res := make([]string, len(langs))
+	for i := range langs {
+		res[i] = langs[i].lang
+	}
+
+	return res
+}
+
+// MatchLanguage choose the best of available languages for requested ones
+//
+// Languages are compared case insensitive, '-' and '_' are equal. Requested
+// languages are checked in order, first exactly, then by base language (so
+// 'pt-BR' matches 'pt' and 'pt' matches 'pt_BR'). Returns language in
+// available form.
+func MatchLanguage(requested, available []string) (string, bool) {
+	normalize := func(lang string) string {
+		return strings.ToLower(strings.ReplaceAll(lang, "-", "_"))
+	}
+	base := func(lang string) string {
+		return strings.SplitN(lang, "_", 2)[0]
+	}
+	for _, req := range requested {
+		req = normalize(req)
+		for _, lang := range available {
+			if normalize(lang) == req {
+				return lang, true
+			}
+		}
+		for _, lang := range available {
+			if normalize(lang) == base(req) {
+				return lang, true
+			}
+		}
+		for _, lang := range available {
+			if base(normalize(lang)) == base(req) {
+				return lang, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// DefaultLanguagesRefresh is a delay to list languages of loader again
+const DefaultLanguagesRefresh = time.Minute
+
+type middlewareConfig struct {
+	cookie    string
+	query     string
+	languages []string
+	refresh   time.Duration
+}
+
+// MiddlewareOption customize language negotiation
+type MiddlewareOption interface {
+	apply(*middlewareConfig)
+}
+
+type fnMiddlewareOption func(*middlewareConfig)
+
+func (fn fnMiddlewareOption) apply(cfg *middlewareConfig) {
+	fn(cfg)
+}
+
+// WithCookie set name of cookie with preferred language
+func WithCookie(name string) MiddlewareOption {
+	return fnMiddlewareOption(func(cfg *middlewareConfig) {
+		cfg.cookie = name
+	})
+}
+
+// WithQueryParam set name of query parameter with preferred language
+func WithQueryParam(name string) MiddlewareOption {
+	return fnMiddlewareOption(func(cfg *middlewareConfig) {
+		cfg.query = name
+	})
+}
+
+// WithLanguages set list of available languages
+//
+// By default languages are listed by loader if it implements LanguageLister.
+func WithLanguages(langs ...string) MiddlewareOption {
+	return fnMiddlewareOption(func(cfg *middlewareConfig) {
+		cfg.languages = langs
+	})
+}
+
+// WithLanguagesRefresh set delay to list languages of loader again
+//
+// By default it is an interval of watcher or DefaultLanguagesRefresh if
+// there is no watcher. Not positive delay disables refresh.
+func WithLanguagesRefresh(delay time.Duration) MiddlewareOption {
+	return fnMiddlewareOption(func(cfg *middlewareConfig) {
+		cfg.refresh = delay
+	})
+}
+
+// Middleware to store negotiated language in request context
+//
+// Language is chosen from query parameter, cookie and Accept-Language header
+// (in order of priority) and matched with available languages. If nothing
+// is matched, context is left as is, so default language of translator
+// will be used. Languages of loader are listed again after refresh delay,
+// so catalogs added later are matched too.
+func (t *Translator) Middleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := middlewareConfig{refresh: DefaultLanguagesRefresh}
+	if t.watchInterval > 0 {
+		cfg.refresh = t.watchInterval
+	}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	languages := func() []string {
+		return cfg.languages
+	}
+	if cfg.languages == nil {
+		if lister, ok := t.loader.(LanguageLister); ok {
+			ll := &languageList{t: t, lister: lister, refresh: cfg.refresh}
+			languages = ll.get
+		} else {
+			t.logger.Printf("loader does not list languages and WithLanguages is not set, " +
+				"so languages are not negotiated")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if lang, ok := MatchLanguage(cfg.requested(r), languages()); ok {
+				r = r.WithContext(ContextWithLanguage(r.Context(), lang))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// languageList is a cache of languages listed by loader
+type languageList struct {
+	t       *Translator
+	lister  LanguageLister
+	refresh time.Duration
+
+	mu       sync.Mutex
+	langs    []string
+	listed   bool
+	listedAt time.Time
+}
+
+// get languages, they are listed again if refresh delay is passed
+func (ll *languageList) get() []string {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+	now := ll.t.now()
+	if ll.listed && (ll.refresh <= 0 || now.Sub(ll.listedAt) < ll.refresh) {
+		return ll.langs
+	}
+	// languages are not listed again until refresh even on error
+	ll.listed, ll.listedAt = true, now
+	langs, err := ll.lister.Languages()
+	if err != nil {
+		ll.t.logger.Printf("error on list languages: %+v", err)
+		return ll.langs
+	}
+	ll.langs = langs
+
+	return langs
+}
+
+func (cfg *middlewareConfig) requested(r *http.Request) []string {
+	var res []string
+	if cfg.query != "" {
+		if lang := r.URL.Query().Get(cfg.query); lang != "" {
+			res = append(res, lang)
+		}
+	}
+	if cfg.cookie != "" {
+		if cookie, err := r.Cookie(cfg.cookie); err == nil && cookie.Value != "" {
+			res = append(res, cookie.Value)
+		}
+	}
+
+	return append(res, ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+}
@@ -0,0 +1,170 @@
+package pogo_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		header string
+		langs  []string
+	}{
+		{"", []string{}},
+		{"ru", []string{"ru"}},
+		{"ru-RU, ru;q=0.9, en-US;q=0.8, en;q=0.7, *;q=0.5", []string{"ru-RU", "ru", "en-US", "en"}},
+		{"en;q=0.5, de, fr;q=0", []string{"de", "en"}},
+		{"en;q=bad, de", []string{"de"}},
+		{"de;q=0.9, en;q=2", []string{"en", "de"}},
+		{"en;q=-1, fr;q=NaN, de", []string{"de"}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.header, func(t *testing.T) {
+			assert.Equal(t, c.langs, pogo.ParseAcceptLanguage(c.header))
+		})
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	t.Parallel()
+
+	available := []string{"en", "pt_BR", "ru"}
+	cases := [...]struct {
+		requested []string
+		lang      string
+		ok        bool
+	}{
+		{[]string{"ru-RU"}, "ru", true},
+		{[]string{"PT-br"}, "pt_BR", true},
+		{[]string{"pt-PT"}, "pt_BR", true},
+		{[]string{"de", "en-GB"}, "en", true},
+		{[]string{"de"}, "", false},
+		{nil, "", false},
+	}
+
+	for _, c := range cases {
+		lang, ok := pogo.MatchLanguage(c.requested, available)
+		assert.Equal(t, c.ok, ok, c.requested)
+		assert.Equal(t, c.lang, lang, c.requested)
+	}
+}
+
+func TestFileLoaderLanguages(t *testing.T) {
+	loader := pogo.FileLoader(new(TranslatorSuite).Pattern())
+	lister, ok := loader.(pogo.LanguageLister)
+	require.True(t, ok)
+	langs, err := lister.Languages()
+	require.NoError(t, err)
+	sort.Strings(langs)
+	assert.Equal(t, []string{"es_ES", "ru"}, langs)
+}
+
+func TestMiddleware(t *testing.T) {
+	tr := pogo.NewTranslator("ru", pogo.FileLoader(new(TranslatorSuite).Pattern()))
+	handler := tr.Middleware(pogo.WithCookie("lang"), pogo.WithQueryParam("lang"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(tr.Translate(r.Context(), "Let’s make the web multilingual.")))
+		}),
+	)
+
+	cases := [...]struct {
+		name   string
+		target string
+		cookie string
+		header string
+		result string
+	}{
+		{"default", "/", "", "", "Сделаем интернет многоязычным."},
+		{"header", "/", "", "de, es;q=0.8, ru;q=0.5", "Hagamos la web multilingüe."},
+		{"cookie", "/", "es", "ru", "Hagamos la web multilingüe."},
+		{"query", "/?lang=ru-RU", "es", "es", "Сделаем интернет многоязычным."},
+		{"unknown", "/?lang=de", "", "", "Сделаем интернет многоязычным."},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.target, nil)
+			if c.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "lang", Value: c.cookie})
+			}
+			if c.header != "" {
+				r.Header.Set("Accept-Language", c.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, c.result, w.Body.String())
+		})
+	}
+}
+
+func TestMiddlewareLanguages(t *testing.T) {
+	var lang string
+	tr := pogo.NewTranslator("ru", pogo.FileLoader(new(TranslatorSuite).Pattern()))
+	handler := tr.Middleware(pogo.WithLanguages("en", "fr"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lang, _ = pogo.LanguageFromContext(r.Context())
+		}),
+	)
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(context.Background())
+	r.Header.Set("Accept-Language", "ru, fr-CA;q=0.5")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "fr", lang)
+}
+
+func TestMiddlewareLanguagesRefresh(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ru/default.po": &fstest.MapFile{Data: []byte("msgid \"One\"\nmsgstr \"Один\"\n")},
+	}
+	now := time.Now()
+	tr := pogo.NewTranslator("ru", pogo.FSLoader(fsys, "{{ language }}/{{ domain }}.{{ ext }}"),
+		pogo.WithClock(func() time.Time { return now }))
+	handler := tr.Middleware(pogo.WithLanguagesRefresh(time.Minute))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(tr.Translate(r.Context(), "One")))
+		}),
+	)
+	get := func() string {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", "es, ru;q=0.5")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	assert.Equal(t, "Один", get())
+
+	fsys["es/default.po"] = &fstest.MapFile{Data: []byte("msgid \"One\"\nmsgstr \"Uno\"\n")}
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, "Один", get())
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, "Uno", get())
+}
+
+func TestMiddlewareWithoutLanguages(t *testing.T) {
+	buf := new(bytes.Buffer)
+	// embedding hides Languages method of loader
+	loader := struct{ pogo.Loader }{pogo.FileLoader(new(TranslatorSuite).Pattern())}
+	tr := pogo.NewTranslator("ru", loader, pogo.WithLogger(log.New(buf, "", 0)))
+	_ = tr.Middleware()
+	assert.Contains(t, buf.String(), "loader does not list languages")
+
+	buf.Reset()
+	_ = tr.Middleware(pogo.WithLanguages("ru"))
+	assert.Empty(t, buf.String())
+}
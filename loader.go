@@ -0,0 +1,196 @@
+package pogo
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by loader if there is no catalog for language and domain
+var ErrNotFound = errors.New("catalog not found")
+
+// IsNotFound report is error caused by missed catalog
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == ErrNotFound
+}
+
+// osFS is a file system to open files by native paths as os.Open do
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name) //nolint:gosec // it is just library
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+type fsLoader struct {
+	fsys    fs.FS
+	pattern string
+}
+
+func (fl fsLoader) Load(lang, domain string) (Locale, error) {
+	loc, err := fl.load(lang, domain)
+	if IsNotFound(err) {
+		langs := strings.SplitN(lang, "_", 2)
+		if len(langs) == 2 {
+			return fl.load(langs[0], domain)
+		}
+	}
+	return loc, err
+}
+
+func (fl fsLoader) load(lang, domain string) (Locale, error) {
+	if moFile, err := fl.fsys.Open(fl.getPath(lang, domain, "mo")); err == nil {
+		defer func() {
+			_ = moFile.Close()
+		}()
+		mo, err := ReadMOFile(moFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse %q", fl.getPath(lang, domain, "mo"))
+		}
+		return mo, nil
+	} else if !os.IsNotExist(err) {
+		return nil, errors.WithStack(err)
+	}
+	poFile, err := fl.fsys.Open(fl.getPath(lang, domain, "po"))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrap(ErrNotFound, fl.getPath(lang, domain, "{mo,po}"))
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = poFile.Close()
+	}()
+	po, err := ReadPOFile(poFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %q", fl.getPath(lang, domain, "po"))
+	}
+	return po.MO(), nil
+}
+
+func (fl fsLoader) getPath(lang, domain, ext string) string {
+	filepath := fl.pattern
+	filepath = strings.Replace(filepath, "{{ language }}", lang, -1) //nolint:gocritic // backward compatibility
+	filepath = strings.Replace(filepath, "{{ domain }}", domain, -1) //nolint:gocritic // backward compatibility
+	filepath = strings.Replace(filepath, "{{ ext }}", ext, -1)       //nolint:gocritic // backward compatibility
+
+	return filepath
+}
+
+// clean pattern as glob do it with matched paths
+func (fl fsLoader) clean(pattern string) string {
+	if _, ok := fl.fsys.(osFS); ok {
+		return filepath.Clean(pattern)
+	}
+	return path.Clean(pattern)
+}
+
+// Languages implements LanguageLister
+func (fl fsLoader) Languages() ([]string, error) {
+	pattern := regexp.QuoteMeta(fl.clean(fl.pattern))
+	pattern = strings.Replace(pattern, regexp.QuoteMeta("{{ language }}"), `([^/\\]+)`, 1)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta("{{ language }}"), `[^/\\]+`, -1)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta("{{ domain }}"), `[^/\\]+`, -1)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta("{{ ext }}"), `(?:mo|po)`, -1)
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	glob := strings.NewReplacer(
+		"{{ language }}", "*",
+		"{{ domain }}", "*",
+		"{{ ext }}", "*",
+	).Replace(fl.clean(fl.pattern))
+	files, err := fs.Glob(fl.fsys, glob)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var langs []string
+	exists := make(map[string]bool)
+	for _, file := range files {
+		sub := re.FindStringSubmatch(file)
+		if len(sub) == 2 && !exists[sub[1]] {
+			langs = append(langs, sub[1])
+			exists[sub[1]] = true
+		}
+	}
+
+	return langs, nil
+}
+
+// ModTime implements ModTimeLoader
+//
+// Returns the latest modification time of all files which may be loaded for
+// given language and domain, or zero time if there are no such files.
+func (fl fsLoader) ModTime(lang, domain string) (time.Time, error) {
+	langs := []string{lang}
+	if split := strings.SplitN(lang, "_", 2); len(split) == 2 {
+		langs = append(langs, split[0])
+	}
+	var res time.Time
+	for _, lang := range langs {
+		for _, ext := range [...]string{"mo", "po"} {
+			info, err := fs.Stat(fl.fsys, fl.getPath(lang, domain, ext))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return time.Time{}, errors.WithStack(err)
+			}
+			if info.ModTime().After(res) {
+				res = info.ModTime()
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// FSLoader loader po and mo files from file system
+//
+// Pattern is the same as for FileLoader, but it should be a valid path of
+// fsys (slash separated, without leading "./"). For example, catalogs
+// embedded with `//go:embed locales` could be loaded with pattern
+// "locales/{{ language }}/{{ domain }}.{{ ext }}".
+func FSLoader(fsys fs.FS, pattern string) Loader {
+	return fsLoader{fsys, pattern}
+}
+
+// FileLoader standard loader po and mo files from disk
+//
+// Pattern should contain next three parts:
+// - `{{ language }}` – language in form 'ru_RU' or 'ru';
+// - `{{ domain }}` – resources domain (default is 'default');
+// - `{{ ext }}` – file extension (mo | po).
+//
+// For example "./data/locales/{{ language }}/{{ domain }}.{{ ext }}".
+//
+// File will be loaded on first request with given language and domain. First
+// loader try to get mo file with full language name. If it is not found, try
+// to get po file with full language name. If it is not found too, loader try
+// to get mo and po files with short language name. Parse and IO errors are
+// returned at once without further tries. So for default domain and language
+// ru_RU sequence of tries in example patter will be next:
+// 1. "./data/locales/ru_RU/default.mo";
+// 2. "./data/locales/ru_RU/default.po";
+// 3. "./data/locales/ru/default.mo";
+// 4. "./data/locales/ru/default.po";
+//
+// Fuzzy, obsolete and untranslated entries of po files are skipped, as
+// msgfmt do it.
+func FileLoader(pattern string) Loader {
+	return fsLoader{osFS{}, pattern}
+}
@@ -0,0 +1,66 @@
+package pogo_test
+
+import (
+	"context"
+	"embed"
+	"sort"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+//go:embed testdata/locales
+var embedLocales embed.FS
+
+func TestFSLoader(t *testing.T) {
+	loader := pogo.FSLoader(embedLocales, "testdata/locales/{{ language }}/{{ domain }}.{{ ext }}")
+
+	tr := pogo.NewTranslator("ru_RU", loader)
+	ctx := context.Background()
+	s := tr.Translate(ctx, "Let’s make the web multilingual.")
+	assert.Equal(t, "Сделаем интернет многоязычным.", s)
+	s = tr.Translate(ctx, "Hello {{ . }}", pogo.WithDomain("domain"), pogo.WithGoTemplate("Женя"))
+	assert.Equal(t, "Привет, Женя", s)
+
+	_, err := loader.Load("de_DE", "default")
+	assert.True(t, pogo.IsNotFound(err), err)
+
+	langs, err := loader.(pogo.LanguageLister).Languages()
+	require.NoError(t, err)
+	sort.Strings(langs)
+	assert.Equal(t, []string{"es_ES", "ru"}, langs)
+}
+
+func TestFSLoaderMapFS(t *testing.T) {
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"ru/default.po": &fstest.MapFile{
+			Data:    []byte("msgid \"One\"\nmsgstr \"Один\"\n"),
+			ModTime: modTime,
+		},
+		"ru/broken.po": &fstest.MapFile{
+			Data: []byte("msgid"),
+		},
+	}
+	loader := pogo.FSLoader(fsys, "{{ language }}/{{ domain }}.{{ ext }}")
+
+	loc, err := loader.Load("ru_RU", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "Один", loc.Get("One"))
+
+	_, err = loader.Load("ru", "broken")
+	require.Error(t, err)
+	assert.False(t, pogo.IsNotFound(err))
+
+	mt, err := loader.(pogo.ModTimeLoader).ModTime("ru_RU", "default")
+	require.NoError(t, err)
+	assert.Equal(t, modTime, mt)
+	mt, err = loader.(pogo.ModTimeLoader).ModTime("en", "default")
+	require.NoError(t, err)
+	assert.True(t, mt.IsZero())
+}
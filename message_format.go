@@ -0,0 +1,455 @@
+package pogo
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// WithMessageFormat format message as ICU MessageFormat
+//
+// Supported arguments are `{name}`, `{name, number}` (with optional style
+// integer or percent), `{name, plural, ...}` (with optional offset, exact
+// `=N` selectors and `#` placeholder) and `{name, select, ...}`. Plural
+// categories are chosen by CLDR rules of language of catalog where message is
+// found (see also WithSourceLanguage).
+func WithMessageFormat(args map[string]interface{}) TranslateOption {
+	return fnTranslateOption(func(cfg translateConfig) translateConfig {
+		cfg.formatter = func(t *Translator, lang, msg string) (string, error) {
+			mf, err := t.messageFormats.parse(msg)
+			if err != nil {
+				return "", err
+			}
+			res := &strings.Builder{}
+			if err := mf.format(res, &mfContext{lang: lang, args: args}); err != nil {
+				return "", err
+			}
+			return res.String(), nil
+		}
+		return cfg
+	})
+}
+
+// mfCacheSize is a limit of parsed messages kept by translator
+const mfCacheSize = 4096
+
+// mfCache of parsed messages, it is dropped when it is full
+//
+// Messages with errors are not cached.
+type mfCache struct {
+	mu sync.RWMutex
+	m  map[string]mfMessage
+}
+
+func (c *mfCache) parse(source string) (mfMessage, error) {
+	c.mu.RLock()
+	msg, ok := c.m[source]
+	c.mu.RUnlock()
+	if ok {
+		return msg, nil
+	}
+	msg, err := parseMessageFormat(source)
+	if err != nil {
+		return msg, err
+	}
+	c.mu.Lock()
+	if c.m == nil || len(c.m) >= mfCacheSize {
+		c.m = make(map[string]mfMessage)
+	}
+	c.m[source] = msg
+	c.mu.Unlock()
+
+	return msg, nil
+}
+
+type mfContext struct {
+	lang string
+	args map[string]interface{}
+	// number to replace '#' in plural message
+	pound float64
+}
+
+func (ctx *mfContext) arg(name string) (interface{}, error) {
+	value, ok := ctx.args[name]
+	if !ok {
+		return nil, errors.Errorf("missed argument %q", name)
+	}
+	return value, nil
+}
+
+func (ctx *mfContext) number(name string) (float64, error) {
+	value, err := ctx.arg(name)
+	if err != nil {
+		return 0, err
+	}
+	switch x := value.(type) {
+	case int:
+		return float64(x), nil
+	case int8:
+		return float64(x), nil
+	case int16:
+		return float64(x), nil
+	case int32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case uint:
+		return float64(x), nil
+	case uint8:
+		return float64(x), nil
+	case uint16:
+		return float64(x), nil
+	case uint32:
+		return float64(x), nil
+	case uint64:
+		return float64(x), nil
+	case float32:
+		return float64(x), nil
+	case float64:
+		return x, nil
+	}
+	return 0, errors.Errorf("argument %q is not a number: %v", name, value)
+}
+
+type mfNode interface {
+	format(w *strings.Builder, ctx *mfContext) error
+}
+
+type mfMessage []mfNode
+
+func (msg mfMessage) format(w *strings.Builder, ctx *mfContext) error {
+	for _, node := range msg {
+		if err := node.format(w, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mfText string
+
+func (text mfText) format(w *strings.Builder, _ *mfContext) error {
+	_, _ = w.WriteString(string(text))
+	return nil
+}
+
+type mfArg struct {
+	name string
+}
+
+func (arg mfArg) format(w *strings.Builder, ctx *mfContext) error {
+	value, err := ctx.arg(arg.name)
+	if err != nil {
+		return err
+	}
+	if _, err := ctx.number(arg.name); err == nil {
+		return mfNumber{name: arg.name}.format(w, ctx)
+	}
+	switch x := value.(type) {
+	case string:
+		_, _ = w.WriteString(x)
+	case interface{ String() string }:
+		_, _ = w.WriteString(x.String())
+	default:
+		return errors.Errorf("argument %q has unsupported type %T", arg.name, value)
+	}
+	return nil
+}
+
+type mfNumber struct {
+	name, style string
+}
+
+func (num mfNumber) format(w *strings.Builder, ctx *mfContext) error {
+	x, err := ctx.number(num.name)
+	if err != nil {
+		return err
+	}
+	_, _ = w.WriteString(formatNumber(x, num.style))
+	return nil
+}
+
+// formatNumber rounds half to even, as ICU do by default
+func formatNumber(x float64, style string) string {
+	switch style {
+	case "integer":
+		return strconv.FormatFloat(math.RoundToEven(x), 'f', -1, 64)
+	case "percent":
+		return strconv.FormatFloat(math.RoundToEven(x*100), 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+type mfPound struct{}
+
+func (mfPound) format(w *strings.Builder, ctx *mfContext) error {
+	_, _ = w.WriteString(formatNumber(ctx.pound, ""))
+	return nil
+}
+
+type mfPlural struct {
+	name   string
+	offset float64
+	cases  map[string]mfMessage
+}
+
+func (pl mfPlural) format(w *strings.Builder, ctx *mfContext) error {
+	x, err := ctx.number(pl.name)
+	if err != nil {
+		return err
+	}
+	msg, ok := pl.cases["="+formatNumber(x, "")]
+	if !ok {
+		msg, ok = pl.cases[pluralCategory(ctx.lang, x-pl.offset)]
+	}
+	if !ok {
+		msg = pl.cases["other"]
+	}
+	sub := *ctx
+	sub.pound = x - pl.offset
+
+	return msg.format(w, &sub)
+}
+
+type mfSelect struct {
+	name  string
+	cases map[string]mfMessage
+}
+
+func (sel mfSelect) format(w *strings.Builder, ctx *mfContext) error {
+	value, err := ctx.arg(sel.name)
+	if err != nil {
+		return err
+	}
+	var key string
+	switch x := value.(type) {
+	case string:
+		key = x
+	case interface{ String() string }:
+		key = x.String()
+	default:
+		return errors.Errorf("argument %q has unsupported type %T", sel.name, value)
+	}
+	msg, ok := sel.cases[key]
+	if !ok {
+		msg = sel.cases["other"]
+	}
+
+	return msg.format(w, ctx)
+}
+
+// maxMessageFormatDepth is a limit of arguments nesting
+const maxMessageFormatDepth = 32
+
+// mfParser is a recursive descent parser of ICU MessageFormat
+type mfParser struct {
+	source string
+	pos    int
+	depth  int
+}
+
+func parseMessageFormat(source string) (mfMessage, error) {
+	p := &mfParser{source: source}
+	var msg mfMessage
+	err := recoverHandledError(func() {
+		msg = p.parseMessage(false)
+		if p.pos < len(p.source) {
+			p.fail("unexpected '%c'", p.source[p.pos])
+		}
+	})
+
+	return msg, err
+}
+
+func (p *mfParser) fail(format string, args ...interface{}) {
+	panic(errors.Wrapf(errors.Errorf(format, args...), "message format at %d", p.pos))
+}
+
+func (p *mfParser) eof() bool {
+	return p.pos >= len(p.source)
+}
+
+func (p *mfParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.source[p.pos]
+}
+
+// parseMessage until end of source or unpaired '}'
+func (p *mfParser) parseMessage(inPlural bool) mfMessage {
+	var (
+		msg  mfMessage
+		text strings.Builder
+	)
+	flush := func() {
+		if text.Len() > 0 {
+			msg = append(msg, mfText(text.String()))
+			text.Reset()
+		}
+	}
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == '}':
+			flush()
+			return msg
+		case c == '{':
+			flush()
+			msg = append(msg, p.parseArgument(inPlural))
+		case c == '#' && inPlural:
+			flush()
+			p.pos++
+			msg = append(msg, mfPound{})
+		case c == '\'':
+			text.WriteString(p.parseQuoted(inPlural))
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+	flush()
+
+	return msg
+}
+
+// parseQuoted handle apostrophe: "''" is an apostrophe, "'{...}'" is literal
+func (p *mfParser) parseQuoted(inPlural bool) string {
+	p.pos++
+	switch c := p.peek(); {
+	case c == '\'':
+		p.pos++
+		return "'"
+	case c == '{' || c == '}' || c == '#' && inPlural:
+	default:
+		return "'"
+	}
+	var res strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+		if c != '\'' {
+			res.WriteByte(c)
+			continue
+		}
+		if p.peek() != '\'' {
+			return res.String()
+		}
+		res.WriteByte('\'')
+		p.pos++
+	}
+
+	return res.String()
+}
+
+func (p *mfParser) parseArgument(inPlural bool) mfNode {
+	p.expect('{')
+	p.depth++
+	defer func() {
+		p.depth--
+	}()
+	if p.depth > maxMessageFormatDepth {
+		p.fail("arguments are nested deeper than %d", maxMessageFormatDepth)
+	}
+	name := p.parseIdent()
+	if name == "" {
+		p.fail("expected argument name")
+	}
+	if p.skipSpaces(); p.peek() == '}' {
+		p.pos++
+		return mfArg{name}
+	}
+	p.expect(',')
+	var node mfNode
+	switch kind := p.parseIdent(); kind {
+	case "number":
+		style := ""
+		if p.skipSpaces(); p.peek() == ',' {
+			p.pos++
+			style = p.parseIdent()
+		}
+		node = mfNumber{name, style}
+	case "plural":
+		p.expect(',')
+		node = p.parsePlural(name)
+	case "select":
+		p.expect(',')
+		node = mfSelect{name, p.parseCases(inPlural)}
+	default:
+		p.fail("unsupported argument type %q", kind)
+	}
+	p.expect('}')
+
+	return node
+}
+
+func (p *mfParser) parsePlural(name string) mfPlural {
+	pl := mfPlural{name: name}
+	p.skipSpaces()
+	if strings.HasPrefix(p.source[p.pos:], "offset:") {
+		p.pos += len("offset:")
+		p.skipSpaces()
+		start := p.pos
+		for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+			p.pos++
+		}
+		offset, err := strconv.Atoi(p.source[start:p.pos])
+		if err != nil {
+			p.fail("invalid offset")
+		}
+		pl.offset = float64(offset)
+	}
+	pl.cases = p.parseCases(true)
+
+	return pl
+}
+
+func (p *mfParser) parseCases(inPlural bool) map[string]mfMessage {
+	cases := make(map[string]mfMessage)
+	for {
+		p.skipSpaces()
+		if p.peek() == '}' || p.eof() {
+			break
+		}
+		selector := p.parseIdent()
+		if selector == "" {
+			p.fail("expected selector")
+		}
+		p.expect('{')
+		cases[selector] = p.parseMessage(inPlural)
+		p.expect('}')
+	}
+	if _, ok := cases["other"]; !ok {
+		p.fail("missed 'other' selector")
+	}
+
+	return cases
+}
+
+func (p *mfParser) parseIdent() string {
+	p.skipSpaces()
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune("{}#,' \t\r\n", rune(p.peek())) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+func (p *mfParser) skipSpaces() {
+	for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+		p.pos++
+	}
+}
+
+func (p *mfParser) expect(c byte) {
+	p.skipSpaces()
+	if p.eof() {
+		p.fail("unexpected end of message, expected '%c'", c)
+	}
+	if p.peek() != c {
+		p.fail("expected '%c'", c)
+	}
+	p.pos++
+}
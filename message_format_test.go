@@ -0,0 +1,102 @@
+package pogo_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestMessageFormat(t *testing.T) {
+	t.Parallel()
+
+	files := `{count, plural, =0 {No files} one {# file} few {# файла} many {# файлов} other {# files}}`
+	gender := `{gender, select, male {He} female {She} other {They}} liked {count, plural, ` +
+		`offset:1 =0 {nobody} =1 {{name}} one {{name} and # other} other {{name} and # others}}`
+
+	cases := [...]struct {
+		name   string
+		lang   string
+		msg    string
+		args   map[string]interface{}
+		result string
+	}{
+		{"text", "en", "Hello, world", nil, "Hello, world"},
+		{"simple", "en", "Hello, {name}!", map[string]interface{}{"name": "John"}, "Hello, John!"},
+		{"number", "en", "{n, number} and {n, number, integer} or {p, number, percent}",
+			map[string]interface{}{"n": 2.5, "p": 0.42}, "2.5 and 2 or 42%"},
+		{"half to even", "en", "{a, number, integer} {b, number, integer} {p, number, percent}",
+			map[string]interface{}{"a": 3.5, "b": 4.5, "p": 0.125}, "4 4 12%"},
+		{"quoted", "en", "It''s '{name}' and '#'", map[string]interface{}{}, "It's {name} and '#'"},
+		{"plural zero", "en", files, map[string]interface{}{"count": 0}, "No files"},
+		{"plural en one", "en", files, map[string]interface{}{"count": 1}, "1 file"},
+		{"plural en other", "en_US", files, map[string]interface{}{"count": 3}, "3 files"},
+		{"plural ru one", "ru", files, map[string]interface{}{"count": 21}, "21 file"},
+		{"plural ru few", "ru_RU", files, map[string]interface{}{"count": 22}, "22 файла"},
+		{"plural ru many", "ru", files, map[string]interface{}{"count": 11}, "11 файлов"},
+		{"plural fraction", "ru", files, map[string]interface{}{"count": 1.5}, "1.5 files"},
+		{"select offset", "en", gender,
+			map[string]interface{}{"gender": "female", "count": 2, "name": "Ann"}, "She liked Ann and 1 other"},
+		{"select other", "en", gender,
+			map[string]interface{}{"gender": "unknown", "count": 5, "name": "Ann"}, "They liked Ann and 4 others"},
+		{"select exact", "en", gender,
+			map[string]interface{}{"gender": "male", "count": 1, "name": "Ann"}, "He liked Ann"},
+		{"untranslated ru", "ru", "{count, plural, one {# item} other {# items}}",
+			map[string]interface{}{"count": 21}, "21 items"},
+	}
+
+	// files message is translated as is to be formatted by rules of ru
+	catalog := "msgid \"\"\nmsgstr \"Language: ru\\n\"\n\nmsgid \"" + files + "\"\nmsgstr \"" + files + "\"\n"
+	fsys := fstest.MapFS{"ru/default.po": &fstest.MapFile{Data: []byte(catalog)}}
+	tr := pogo.NewTranslator("en", pogo.FSLoader(fsys, "{{ language }}/{{ domain }}.{{ ext }}"),
+		pogo.WithLogger(log.New(new(bytes.Buffer), "", 0)))
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			ctx := pogo.ContextWithLanguage(context.Background(), c.lang)
+			assert.Equal(t, c.result, tr.Translate(ctx, c.msg, pogo.WithMessageFormat(c.args)))
+		})
+	}
+
+	tr = pogo.NewTranslator("en", pogo.FSLoader(fsys, "{{ language }}/{{ domain }}.{{ ext }}"),
+		pogo.WithLogger(log.New(new(bytes.Buffer), "", 0)), pogo.WithSourceLanguage("ru"))
+	assert.Equal(t, "21 item", tr.Translate(context.Background(), "{count, plural, one {# item} other {# items}}",
+		pogo.WithMessageFormat(map[string]interface{}{"count": 21})))
+}
+
+func TestMessageFormatErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name string
+		msg  string
+		args map[string]interface{}
+		err  string
+	}{
+		{"unclosed", "Hello, {name", map[string]interface{}{"name": "John"}, "unexpected end of message"},
+		{"unpaired", "Hello, name}", nil, "unexpected '}'"},
+		{"unknown type", "{n, date}", map[string]interface{}{"n": 1}, `unsupported argument type "date"`},
+		{"no other", "{n, plural, one {#}}", map[string]interface{}{"n": 1}, "missed 'other' selector"},
+		{"missed argument", "Hello, {name}", nil, `missed argument "name"`},
+		{"not a number", "{n, number}", map[string]interface{}{"n": "one"}, `argument "n" is not a number`},
+		{"too deep", strings.Repeat("{s, select, other {", 40) + strings.Repeat("}}", 40),
+			map[string]interface{}{"s": "x"}, "arguments are nested deeper than 32"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			tr := pogo.NewTranslator("en", pogo.FSLoader(fstest.MapFS{}, "{{ language }}/{{ domain }}.{{ ext }}"),
+				pogo.WithLogger(log.New(buf, "", 0)))
+			assert.Equal(t, c.msg, tr.Translate(context.Background(), c.msg, pogo.WithMessageFormat(c.args)))
+			assert.Contains(t, buf.String(), c.err)
+		})
+	}
+}
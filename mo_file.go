@@ -1,8 +1,11 @@
 package pogo
 
 import (
+	"encoding/binary"
 	"io"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 const (
@@ -17,8 +20,11 @@ type MOFile struct {
 }
 
 // ReadMOFile from reader
-func ReadMOFile(r io.Reader) (*MOFile, error) {
-	mr := moReader{r: r}
+//
+// Strings are decoded to UTF-8 by charset declared in header, unknown
+// charset is reported as invalid mo file.
+func ReadMOFile(r io.Reader, opts ...MOReaderOption) (*MOFile, error) {
+	mr := newMOReader(r, opts)
 	if err := mr.Read(); err != nil {
 		return nil, err
 	}
@@ -26,54 +32,108 @@ func ReadMOFile(r io.Reader) (*MOFile, error) {
 	file := &MOFile{
 		Entries: make(map[string][]string, mr.N),
 	}
-	for i := 0; i < int(mr.N); i++ {
-		id := mr.Originals[i]
-		str := mr.Translations[i]
-		if id == "" {
-			file.Header.parseEntryMsgStr(str)
-		} else {
-			file.Entries[id] = strings.Split(str, pluralSep)
+	// only the first header is used as gettext do it
+	header := -1
+	for i := range mr.Originals {
+		if mr.Originals[i] == "" {
+			header = i
+			break
+		}
+	}
+	var cs *charset
+	if header >= 0 {
+		var err error
+		cs, err = charsetOfContentType(headerValue(mr.Translations[header], "Content-Type"))
+		if err != nil {
+			return nil, moParseError(mr.filename, mr.TTable[header].Offset, errors.Wrap(errMOFile, err.Error()))
+		}
+		file.Header.parseEntryMsgStr(cs.decode(mr.Translations[header]))
+	}
+	for i := range mr.Originals {
+		if mr.Originals[i] != "" {
+			id := cs.decode(mr.Originals[i])
+			file.Entries[id] = strings.Split(cs.decode(mr.Translations[i]), pluralSep)
 		}
 	}
+	file.Header.fallbackPluralForms()
 
 	return file, nil
 }
 
-// Print file to writer
-func (file *MOFile) Write(w io.Writer) error {
-	mw := moWriter{w: w, file: file}
+// Write file to writer
+//
+// Strings are encoded by charset declared in header (see also
+// WithWriteCharset option).
+func (file *MOFile) Write(w io.Writer, opts ...WriteOption) error {
+	mw := moWriter{w: w, file: file, order: binary.LittleEndian}
+	for _, opt := range opts {
+		opt(&mw)
+	}
 	return mw.Write()
 }
 
 // Get translation by original
 func (file *MOFile) Get(msg string) string {
-	return file.Entries[msg][0]
+	str, _ := file.Lookup(msg, "", nil, -1)
+	return str
 }
 
 // GetN plural translation by original
 func (file *MOFile) GetN(msg, plural string, n int) string {
-	i := file.PluralForms.Eval(n)
-	id := msg + pluralSep + plural
-	forms := file.Entries[id]
-	if i >= len(forms) {
-		return ""
-	}
-	return forms[i]
+	str, _ := file.Lookup(msg, plural, nil, n)
+	return str
 }
 
 // GetCtxt translation by original and context
+//
+// Empty context is a context too, as in pgettext.
 func (file *MOFile) GetCtxt(msg, ctxt string) string {
-	id := ctxt + ctxtSep + msg
-	return file.Entries[id][0]
+	str, _ := file.Lookup(msg, "", &ctxt, -1)
+	return str
 }
 
 // GetCtxtN plural translation by original and context
 func (file *MOFile) GetCtxtN(msg, plural, ctxt string, n int) string {
-	i := file.PluralForms.Eval(n)
-	id := ctxt + ctxtSep + msg + pluralSep + plural
-	forms := file.Entries[id]
-	if i >= len(forms) {
-		return ""
+	str, _ := file.Lookup(msg, plural, &ctxt, n)
+	return str
+}
+
+// Lookup translation and report is it found
+//
+// Plural form is used if plural is not empty, context is used if ctxt is not
+// nil. If there is no translation or it is empty, original is returned as
+// gettext do it.
+func (file *MOFile) Lookup(msg, plural string, ctxt *string, n int) (string, bool) {
+	id := msg
+	if ctxt != nil {
+		id = messageID(true, *ctxt, msg)
+	}
+	if plural == "" {
+		if forms := file.Entries[id]; len(forms) > 0 && forms[0] != "" {
+			return forms[0], true
+		}
+		return msg, false
+	}
+	forms := file.Entries[id+pluralSep+plural]
+	if i := file.PluralForms.Eval(n); i < len(forms) && forms[i] != "" {
+		return forms[i], true
+	}
+
+	return sourcePlural(msg, plural, n), false
+}
+
+// messageID join context and original as gettext do it
+func messageID(hasCtxt bool, ctxt, msg string) string {
+	if hasCtxt {
+		return ctxt + ctxtSep + msg
+	}
+	return msg
+}
+
+// sourcePlural choose original form by germanic rule
+func sourcePlural(msg, plural string, n int) string {
+	if n == 1 {
+		return msg
 	}
-	return forms[i]
+	return plural
 }
@@ -2,7 +2,10 @@ package pogo_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,6 +32,60 @@ func TestMOFile(t *testing.T) {
 		mo.GetN("%d page read.", "%d pages read.", 22))
 }
 
+func TestMOFileFallback(t *testing.T) {
+	mo := &pogo.MOFile{
+		Entries: map[string][]string{
+			"Empty":                     {""},
+			"%d file\x00%d files":       {"%d файл", ""},
+			"menu\x04Open":              {"Открыть"},
+			"menu\x04%d tab\x00%d tabs": {"%d вкладка", "%d вкладки"},
+			"\x04Close":                 {"Закрыть"},
+		},
+	}
+	mo.PluralForms, _ = pogo.ParsePluralRules("nplurals=2; plural=n != 1;")
+
+	assert.Equal(t, "Missed", mo.Get("Missed"))
+	assert.Equal(t, "Empty", mo.Get("Empty"))
+	assert.Equal(t, "Open", mo.Get("Open"))
+	assert.Equal(t, "Открыть", mo.GetCtxt("Open", "menu"))
+	assert.Equal(t, "Open", mo.GetCtxt("Open", "file"))
+	assert.Equal(t, "%d файл", mo.GetN("%d file", "%d files", 1))
+	assert.Equal(t, "%d files", mo.GetN("%d file", "%d files", 2))
+	assert.Equal(t, "%d page", mo.GetN("%d page", "%d pages", 1))
+	assert.Equal(t, "%d pages", mo.GetN("%d page", "%d pages", 0))
+	assert.Equal(t, "%d вкладки", mo.GetCtxtN("%d tab", "%d tabs", "menu", 5))
+	assert.Equal(t, "%d tabs", mo.GetCtxtN("%d tab", "%d tabs", "window", 5))
+
+	assert.Equal(t, "Close", mo.Get("Close"))
+	assert.Equal(t, "Закрыть", mo.GetCtxt("Close", ""))
+
+	ctxt := "menu"
+	str, ok := mo.Lookup("Open", "", &ctxt, -1)
+	assert.True(t, ok)
+	assert.Equal(t, "Открыть", str)
+	str, ok = mo.Lookup("Empty", "", nil, -1)
+	assert.False(t, ok)
+	assert.Equal(t, "Empty", str)
+}
+
+func TestMOFilePluralFormsFallback(t *testing.T) {
+	mo := &pogo.MOFile{
+		Entries: map[string][]string{
+			"%d file\x00%d files": {"%d файл", "%d файла", "%d файлов"},
+		},
+	}
+	mo.Language = "ru"
+	buf := &bytes.Buffer{}
+	require.NoError(t, mo.Write(buf))
+	data := bytes.Replace(buf.Bytes(), []byte("plural=0;"), []byte("plural=?;"), 1)
+
+	mo, err := pogo.ReadMOFile(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 3, mo.PluralForms.Len())
+	assert.Equal(t, "%d файла", mo.GetN("%d file", "%d files", 22))
+	assert.Equal(t, "%d файлов", mo.GetN("%d file", "%d files", 25))
+}
+
 func TestMOFileWrite(t *testing.T) {
 	data := golden.Get(t, "example.mo")
 	buf := bytes.NewBuffer(data)
@@ -53,3 +110,231 @@ func TestMOFileWrite(t *testing.T) {
 	assert.Equal(t, "%d страницы прочитаны.",
 		mo.GetN("%d page read.", "%d pages read.", 22))
 }
+
+func TestReadMOFileErrors(t *testing.T) {
+	data := golden.Get(t, "example.mo")
+
+	_, err := pogo.ReadMOFile(bytes.NewReader(data[:len(data)/2]), pogo.WithMOFilename("ru.mo"))
+	require.Error(t, err)
+	perr, ok := err.(*pogo.ParseError)
+	require.True(t, ok)
+	assert.Equal(t, "ru.mo", perr.Filename)
+	assert.Equal(t, pogo.KindInvalidMO, perr.Kind)
+	assert.Contains(t, err.Error(), "unexpected end of file")
+
+	_, err = pogo.ReadMOFile(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8}))
+	require.Error(t, err)
+	assert.Equal(t, "offset 4: magic number mistmatch: invalid mo file", err.Error())
+}
+
+func TestReadMOFileShortReads(t *testing.T) {
+	t.Parallel()
+
+	data := golden.Get(t, "example.mo")
+	for name, r := range map[string]io.Reader{
+		"one byte":  iotest.OneByteReader(bytes.NewReader(data)),
+		"half":      iotest.HalfReader(bytes.NewReader(data)),
+		"data err":  iotest.DataErrReader(bytes.NewReader(data)),
+		"revision1": iotest.OneByteReader(bytes.NewReader(sysdepMO(binary.LittleEndian))),
+	} {
+		mo, err := pogo.ReadMOFile(r)
+		require.NoError(t, err, name)
+		assert.Equal(t, "ru", mo.Language, name)
+	}
+}
+
+func TestReadMOFileStringsBeforeTables(t *testing.T) {
+	t.Parallel()
+
+	originals := []string{"", "Hello"}
+	translations := []string{"Content-Type: text/plain; charset=UTF-8\nLanguage: ru\n", "Привет"}
+	data := make([]byte, 28)
+	var positions []uint32
+	for _, str := range append(originals, translations...) {
+		positions = append(positions, uint32(len(str)), uint32(len(data)))
+		data = append(append(data, str...), 0)
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+	for i, x := range []uint32{0x950412de, 0, 2, uint32(len(data)), uint32(len(data)) + 16, 0, 0} {
+		binary.LittleEndian.PutUint32(data[i*4:], x)
+	}
+	for _, x := range positions {
+		data = append(data, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[len(data)-4:], x)
+	}
+
+	mo, err := pogo.ReadMOFile(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "ru", mo.Language)
+	assert.Equal(t, "Привет", mo.Get("Hello"))
+
+	loc, err := pogo.OpenMOLocaleBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, "ru", loc.Language)
+	assert.Equal(t, "Привет", loc.Get("Hello"))
+}
+
+func TestReadMOFileLimits(t *testing.T) {
+	t.Parallel()
+
+	data := golden.Get(t, "example.mo")
+	// string of 1GB length far beyond the end of file
+	huge := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(huge[28+8:], 1<<30)
+	// 8M strings in tables
+	many := append([]byte(nil), data[:28]...)
+	binary.LittleEndian.PutUint32(many[8:], 1<<23)
+	binary.LittleEndian.PutUint32(many[16:], 28+8<<23)
+	binary.LittleEndian.PutUint32(many[24:], 28+16<<23)
+
+	cases := [...]struct {
+		name string
+		data []byte
+		opts []pogo.MOReaderOption
+		err  string
+	}{
+		{
+			name: "file size",
+			data: data,
+			opts: []pogo.MOReaderOption{pogo.WithMaxMOSize(512)},
+			err:  "offset 8: file is out of size limit: invalid mo file",
+		},
+		{
+			name: "string size",
+			data: data,
+			opts: []pogo.MOReaderOption{pogo.WithMaxMOStringSize(16)},
+			err:  "offset 44: string is out of size limit: invalid mo file",
+		},
+		{
+			name: "huge string",
+			data: huge,
+			err:  "offset 44: string is out of size limit: invalid mo file",
+		},
+		{
+			name: "huge tables",
+			data: many,
+			err:  "offset 28: unexpected end of file: invalid mo file",
+		},
+		{
+			name: "huge tables out of limit",
+			data: many,
+			opts: []pogo.MOReaderOption{pogo.WithMaxMOSize(1 << 20)},
+			err:  "offset 16: original table is out of size limit: invalid mo file",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := pogo.ReadMOFile(bytes.NewReader(c.data), c.opts...)
+			assert.EqualError(t, err, c.err)
+			// locale reads strings lazily, so broken ones are just missed
+			loc, err := pogo.OpenMOLocaleBytes(c.data, c.opts...)
+			if err == nil {
+				assert.Equal(t, "%d pages read.", loc.GetN("%d page read.", "%d pages read.", 5))
+			}
+		})
+	}
+}
+
+func TestMOFileByteOrder(t *testing.T) {
+	t.Parallel()
+
+	mo := &pogo.MOFile{
+		Header:  pogo.Header{Language: "ru"},
+		Entries: map[string][]string{"Open": {"Открыть"}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, mo.Write(buf, pogo.WithByteOrder(binary.BigEndian)))
+	assert.Equal(t, []byte{0x95, 0x04, 0x12, 0xde}, buf.Bytes()[:4])
+
+	res, err := pogo.ReadMOFile(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "Открыть", res.Get("Open"))
+	loc, err := pogo.OpenMOLocaleBytes(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "Открыть", loc.Get("Open"))
+	assert.Equal(t, "ru", loc.Language)
+}
+
+func TestMOFileSysdep(t *testing.T) {
+	t.Parallel()
+
+	for _, order := range [...]binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := sysdepMO(order)
+
+		mo, err := pogo.ReadMOFile(bytes.NewReader(data))
+		require.NoError(t, err, order)
+		assert.Equal(t, "ru", mo.Language)
+		assert.Equal(t, "Скопировано %lu байт", mo.Get("Copied %lu bytes"), order)
+
+		loc, err := pogo.OpenMOLocaleBytes(data)
+		require.NoError(t, err, order)
+		assert.Equal(t, "ru", loc.Language)
+		assert.Equal(t, "Скопировано %lu байт", loc.Get("Copied %lu bytes"), order)
+	}
+}
+
+func TestReadMOFileOverlappedSegments(t *testing.T) {
+	t.Parallel()
+
+	// 64 segments refer to the same long string
+	data := sysdepMO(binary.LittleEndian)
+	str := uint32(len(data))
+	data = append(data, bytes.Repeat([]byte{'x'}, 4096)...)
+	data = append(data, 0, 0, 0, 0)
+	table := uint32(len(data)) &^ 3
+	data = data[:table]
+	for i := 0; i < 64; i++ {
+		data = append(data, make([]byte, 8)...)
+		binary.LittleEndian.PutUint32(data[len(data)-8:], 4096)
+		binary.LittleEndian.PutUint32(data[len(data)-4:], str)
+	}
+	binary.LittleEndian.PutUint32(data[28:], 64)
+	binary.LittleEndian.PutUint32(data[32:], table)
+
+	_, err := pogo.ReadMOFile(bytes.NewReader(data))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "system dependent segments overlap")
+	_, err = pogo.OpenMOLocaleBytes(data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "system dependent segments overlap")
+	_, err = pogo.OpenMOLocale(bytes.NewReader(data))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "system dependent segments overlap")
+}
+
+// sysdepMO build revision 1 file with header and one system dependent string
+// "Copied %<PRIu64> bytes" translated as "Скопировано %<PRIu64> байт"
+func sysdepMO(order binary.ByteOrder) []byte {
+	const end = 0xffffffff
+	header := "Language: ru\n"
+	origParts := [2]string{"Copied %", " bytes"}
+	transParts := [2]string{"Скопировано %", " байт"}
+	strs := []string{"", header, "PRIu64", origParts[0] + origParts[1], transParts[0] + transParts[1]}
+	offsets := make([]uint32, len(strs))
+	offset := uint32(120)
+	for i := range strs {
+		offsets[i] = offset
+		offset += uint32(len(strs[i])) + 1
+	}
+	words := []uint32{
+		0x950412de, 1, 1, 48, 56, 0, 64, // revision 0 headers
+		1, 64, 1, 72, 76, // system dependent headers
+		0, offsets[0], // originals
+		uint32(len(header)), offsets[1], // translations
+		6, offsets[2], // segments
+		80, 100, // system dependent originals and translations
+		offsets[3], uint32(len(origParts[0])), 0, uint32(len(origParts[1])), end,
+		offsets[4], uint32(len(transParts[0])), 0, uint32(len(transParts[1])), end,
+	}
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, order, words)
+	for i := range strs {
+		buf.WriteString(strs[i] + "\x00")
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,52 @@
+//go:build go1.18
+// +build go1.18
+
+package pogo_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/vporoshok/pogo"
+)
+
+// FuzzReadMOFile checks that broken mo files are reported as ParseError
+//
+// Interesting inputs are kept in testdata/fuzz/FuzzReadMOFile.
+func FuzzReadMOFile(f *testing.F) {
+	data, err := os.ReadFile("testdata/example.mo")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add(sysdepMO(binary.LittleEndian))
+	f.Add(sysdepMO(binary.BigEndian))
+
+	opts := []pogo.MOReaderOption{pogo.WithMaxMOSize(1 << 20), pogo.WithMaxMOStringSize(1 << 16)}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		mo, err := pogo.ReadMOFile(bytes.NewReader(data), opts...)
+		if err != nil {
+			if _, ok := errors.Cause(err).(*pogo.ParseError); !ok {
+				t.Fatalf("unexpected error type %T: %v", err, err)
+			}
+		} else {
+			_ = mo.GetN("%d page read.", "%d pages read.", 5)
+		}
+		for _, open := range [...]func() (*pogo.MOLocale, error){
+			func() (*pogo.MOLocale, error) { return pogo.OpenMOLocaleBytes(data, opts...) },
+			func() (*pogo.MOLocale, error) { return pogo.OpenMOLocale(bytes.NewReader(data), opts...) },
+		} {
+			loc, err := open()
+			if err != nil {
+				continue
+			}
+			_ = loc.Get("Let’s make the web multilingual.")
+			_ = loc.GetCtxtN("%d page read.", "%d pages read.", "", 5)
+			_ = loc.Get("Copied %lu bytes")
+		}
+	})
+}
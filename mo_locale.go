@@ -0,0 +1,401 @@
+package pogo
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MOLocale is a Locale which reads strings of mo file on demand
+//
+// Only header is parsed on open, unknown charset of header is reported as
+// invalid mo file. Originals are searched by hash table of
+// file, or by binary search if there is no table, so locale is cheap to open
+// and keeps nothing in memory but header. Strings of byte slice source are
+// not copied until they are returned. MOLocale is safe for concurrent use if
+// its source is.
+type MOLocale struct {
+	Header
+
+	moTables
+	charset *charset
+	// sysdep translations by originals, they are expanded on open
+	sysdep map[string]string
+
+	n uint32 // number of strings
+	o uint32 // offset of table with original strings
+	t uint32 // offset of table with translation strings
+	s uint32 // size of hashing table
+	h uint32 // offset of hashing table
+}
+
+// moSource is a random access storage of mo file
+type moSource interface {
+	// slice of n bytes at offset
+	slice(offset, n uint32) ([]byte, error)
+	// size of source or its limit if size is unknown
+	size() uint64
+}
+
+type readerAtSource struct {
+	r     io.ReaderAt
+	limit uint64
+}
+
+func (src readerAtSource) size() uint64 {
+	return src.limit
+}
+
+func (src readerAtSource) slice(offset, n uint32) ([]byte, error) {
+	if uint64(offset)+uint64(n) > src.limit {
+		return nil, errors.Wrap(errMOFile, "string is out of size limit")
+	}
+	buf := make([]byte, n)
+	read, err := src.r.ReadAt(buf, int64(offset))
+	if read == len(buf) {
+		return buf, nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return nil, errors.WithStack(err)
+}
+
+type bytesSource []byte
+
+func (src bytesSource) slice(offset, n uint32) ([]byte, error) {
+	end := uint64(offset) + uint64(n)
+	if end > uint64(len(src)) {
+		return nil, errors.WithStack(io.ErrUnexpectedEOF)
+	}
+
+	return src[offset:end:end], nil
+}
+
+func (src bytesSource) size() uint64 {
+	return uint64(len(src))
+}
+
+// moTables gives random access to tables of mo file
+type moTables struct {
+	src           moSource
+	order         binary.ByteOrder
+	maxStringSize uint64
+}
+
+// fits reports is region inside of source
+func (mt moTables) fits(offset, n uint64) bool {
+	return offset+n <= mt.src.size()
+}
+
+func (mt moTables) uint32At(offset uint32) (uint32, error) {
+	data, err := mt.src.slice(offset, 4)
+	if err != nil {
+		return 0, err
+	}
+
+	return mt.order.Uint32(data), nil
+}
+
+// position of i-th string of table
+func (mt moTables) position(table, i uint32) (position, error) {
+	data, err := mt.src.slice(table+i*8, 8)
+	if err != nil {
+		return position{}, err
+	}
+	pos := position{
+		Length: mt.order.Uint32(data),
+		Offset: mt.order.Uint32(data[4:]),
+	}
+	if uint64(pos.Length) > mt.maxStringSize {
+		return position{}, errors.Wrap(errMOFile, "string is out of size limit")
+	}
+
+	return pos, nil
+}
+
+// str returns i-th string of table without trailing null byte
+func (mt moTables) str(table, i uint32) ([]byte, error) {
+	pos, err := mt.position(table, i)
+	if err != nil {
+		return nil, err
+	}
+
+	return mt.src.slice(pos.Offset, pos.Length)
+}
+
+// OpenMOLocale over random access reader, as opened file
+//
+// Size of reader with Size or Stat method (as bytes.Reader or os.File) is
+// checked against size limit and bounds work on open, otherwise the limit
+// is used as size. Reader should not be closed while locale is used.
+func OpenMOLocale(r io.ReaderAt, opts ...MOReaderOption) (*MOLocale, error) {
+	mr := newMOReader(nil, opts)
+	limit := mr.maxSize
+	if size, ok := readerAtSize(r); ok {
+		if uint64(size) > mr.maxSize {
+			return nil, moParseError(mr.filename, 0, errors.Wrap(errMOFile, "file is out of size limit"))
+		}
+		limit = uint64(size)
+	}
+	return openMOLocale(readerAtSource{r, limit}, mr)
+}
+
+// readerAtSize returns size of reader if it is known
+func readerAtSize(r io.ReaderAt) (int64, bool) {
+	switch sr := r.(type) {
+	case interface{ Size() int64 }:
+		return sr.Size(), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		if info, err := sr.Stat(); err == nil {
+			return info.Size(), true
+		}
+	}
+
+	return 0, false
+}
+
+// OpenMOLocaleBytes over content of mo file, as memory mapped file
+//
+// Data should not be changed while locale is used.
+func OpenMOLocaleBytes(data []byte, opts ...MOReaderOption) (*MOLocale, error) {
+	mr := newMOReader(nil, opts)
+	if uint64(len(data)) > mr.maxSize {
+		return nil, moParseError(mr.filename, 0, errors.Wrap(errMOFile, "file is out of size limit"))
+	}
+	return openMOLocale(bytesSource(data), mr)
+}
+
+func openMOLocale(src moSource, mr *moReader) (*MOLocale, error) {
+	loc := &MOLocale{moTables: moTables{src: src, maxStringSize: mr.maxStringSize}}
+	offset, err := loc.open()
+	if err != nil {
+		return nil, moParseError(mr.filename, offset, err)
+	}
+
+	return loc, nil
+}
+
+// open reads fixed headers and header entry, returns offset of failure
+func (loc *MOLocale) open() (uint32, error) {
+	data, err := loc.src.slice(0, 28)
+	if err != nil {
+		return 0, err
+	}
+	switch binary.LittleEndian.Uint32(data) {
+	case magic:
+		loc.order = binary.LittleEndian
+	case magicSwapped:
+		loc.order = binary.BigEndian
+	default:
+		return 0, errors.Wrap(errMOFile, "magic number mistmatch")
+	}
+	fields := make([]uint32, 7)
+	for i := range fields {
+		fields[i] = loc.order.Uint32(data[i*4:])
+	}
+	if fields[1] != 0 && fields[1] != 1 {
+		return 4, errors.Wrapf(errMOFile, "unsupported format revision %d", fields[1])
+	}
+	loc.n, loc.o, loc.t, loc.s, loc.h = fields[2], fields[3], fields[4], fields[5], fields[6]
+	if fields[1] == 1 {
+		if offset, err := loc.openSysdep(); err != nil {
+			return offset, err
+		}
+	}
+	switch {
+	case loc.n == 0:
+		loc.Header.fallbackPluralForms()
+		return 0, nil
+	case loc.o < 28 || !loc.fits(uint64(loc.o), uint64(loc.n)*8):
+		return 12, errors.Wrap(errMOFile, "bad original table offset")
+	case uint64(loc.t) < uint64(loc.o)+uint64(loc.n)*8 || !loc.fits(uint64(loc.t), uint64(loc.n)*8):
+		return 16, errors.Wrap(errMOFile, "bad translation table offset")
+	case loc.s > 0 && (uint64(loc.h) < uint64(loc.t)+uint64(loc.n)*8 || !loc.fits(uint64(loc.h), uint64(loc.s)*4)):
+		return 24, errors.Wrap(errMOFile, "bad hashing table offset")
+	}
+
+	i, ok, err := loc.find("")
+	if err != nil {
+		return loc.o, err
+	}
+	if ok {
+		str, err := loc.str(loc.t, i)
+		if err != nil {
+			return loc.t + i*8, err
+		}
+		if loc.charset, err = charsetOfContentType(headerValue(string(str), "Content-Type")); err != nil {
+			return loc.t + i*8, errors.Wrap(errMOFile, err.Error())
+		}
+		loc.Header.parseEntryMsgStr(loc.charset.decode(string(str)))
+	}
+	loc.Header.fallbackPluralForms()
+
+	return 0, nil
+}
+
+// openSysdep expands system dependent strings of revision 1 file
+func (loc *MOLocale) openSysdep() (uint32, error) {
+	data, err := loc.src.slice(28, 20)
+	if err != nil {
+		return 28, err
+	}
+	var tables sysdepTables
+	for i, field := range [...]*uint32{
+		&tables.SegmentsN, &tables.SegmentsOffset, &tables.N, &tables.O, &tables.T,
+	} {
+		*field = loc.order.Uint32(data[i*4:])
+	}
+	originals, translations, err := tables.read(loc.moTables)
+	if err != nil {
+		return tables.O, err
+	}
+	loc.sysdep = make(map[string]string, len(originals))
+	for i := range originals {
+		loc.sysdep[originals[i]] = translations[i]
+	}
+
+	return 0, nil
+}
+
+// find index of original, hash table is used if there is one
+func (loc *MOLocale) find(id string) (uint32, bool, error) {
+	if loc.s < 3 {
+		return loc.search(id)
+	}
+	hash := hashPJW(id)
+	idx := hash % loc.s
+	incr := 1 + hash%(loc.s-2)
+	for probe := uint32(0); probe < loc.s; probe++ {
+		cell, err := loc.uint32At(loc.h + idx*4)
+		if err != nil {
+			return 0, false, err
+		}
+		if cell == 0 {
+			return 0, false, nil
+		}
+		// cells after static strings refer to system dependent ones
+		if cell <= loc.n {
+			pos, err := loc.position(loc.o, cell-1)
+			if err != nil {
+				return 0, false, err
+			}
+			if int(pos.Length) == len(id) {
+				orig, err := loc.src.slice(pos.Offset, pos.Length)
+				if err != nil {
+					return 0, false, err
+				}
+				if string(orig) == id {
+					return cell - 1, true, nil
+				}
+			}
+		}
+		if idx >= loc.s-incr {
+			idx -= loc.s - incr
+		} else {
+			idx += incr
+		}
+	}
+
+	return 0, false, nil
+}
+
+// search index of original by binary search over sorted originals
+func (loc *MOLocale) search(id string) (uint32, bool, error) {
+	lo, hi := uint32(0), loc.n
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		orig, err := loc.str(loc.o, mid)
+		if err != nil {
+			return 0, false, err
+		}
+		switch s := string(orig); {
+		case s == id:
+			return mid, true, nil
+		case s < id:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, false, nil
+}
+
+// forms of translation, nil if there is no such original or file is broken
+func (loc *MOLocale) forms(id string) []string {
+	key, err := loc.charset.encode(id)
+	if err != nil {
+		return nil
+	}
+	i, ok, err := loc.find(key)
+	if err != nil {
+		return nil
+	}
+	if !ok {
+		str, ok := loc.sysdep[key]
+		if !ok {
+			return nil
+		}
+		return strings.Split(loc.charset.decode(str), pluralSep)
+	}
+	str, err := loc.str(loc.t, i)
+	if err != nil {
+		return nil
+	}
+
+	return strings.Split(loc.charset.decode(string(str)), pluralSep)
+}
+
+// Get translation by original
+func (loc *MOLocale) Get(msg string) string {
+	str, _ := loc.Lookup(msg, "", nil, -1)
+	return str
+}
+
+// GetN plural translation by original
+func (loc *MOLocale) GetN(msg, plural string, n int) string {
+	str, _ := loc.Lookup(msg, plural, nil, n)
+	return str
+}
+
+// GetCtxt translation by original and context
+//
+// Empty context is a context too, as in pgettext.
+func (loc *MOLocale) GetCtxt(msg, ctxt string) string {
+	str, _ := loc.Lookup(msg, "", &ctxt, -1)
+	return str
+}
+
+// GetCtxtN plural translation by original and context
+func (loc *MOLocale) GetCtxtN(msg, plural, ctxt string, n int) string {
+	str, _ := loc.Lookup(msg, plural, &ctxt, n)
+	return str
+}
+
+// Lookup translation and report is it found
+//
+// Lookup works as MOFile.Lookup do. Broken file is treated as missed
+// translation.
+func (loc *MOLocale) Lookup(msg, plural string, ctxt *string, n int) (string, bool) {
+	id := msg
+	if ctxt != nil {
+		id = messageID(true, *ctxt, msg)
+	}
+	if plural == "" {
+		if forms := loc.forms(id); len(forms) > 0 && forms[0] != "" {
+			return forms[0], true
+		}
+		return msg, false
+	}
+	forms := loc.forms(id + pluralSep + plural)
+	if i := loc.PluralForms.Eval(n); i < len(forms) && forms[i] != "" {
+		return forms[i], true
+	}
+
+	return sourcePlural(msg, plural, n), false
+}
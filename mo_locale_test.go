@@ -0,0 +1,114 @@
+package pogo_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/golden"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestMOLocale(t *testing.T) {
+	t.Parallel()
+
+	data := golden.Get(t, "example.mo")
+	noHash := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(noHash[20:], 0)
+	file, err := os.Open("testdata/example.mo")
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+
+	open := map[string]func() (*pogo.MOLocale, error){
+		"bytes":         func() (*pogo.MOLocale, error) { return pogo.OpenMOLocaleBytes(data) },
+		"reader at":     func() (*pogo.MOLocale, error) { return pogo.OpenMOLocale(file) },
+		"binary search": func() (*pogo.MOLocale, error) { return pogo.OpenMOLocaleBytes(noHash) },
+	}
+	for name, fn := range open {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			loc, err := fn()
+			require.NoError(t, err)
+			assert.Equal(t, "ru", loc.Language)
+			assert.Equal(t, 3, loc.PluralForms.Len())
+			assert.Equal(t, "Сделаем интернет многоязычным.",
+				loc.Get("Let’s make the web multilingual."))
+			assert.Equal(t, "Добро пожаловать? %s! Ваш последний визит был %s",
+				loc.GetCtxt("Welcome back, %s! Your last visit was on %s", "header"))
+			assert.Equal(t, "Welcome back, %s! Your last visit was on %s",
+				loc.Get("Welcome back, %s! Your last visit was on %s"))
+			assert.Equal(t, "%d страница прочитана.",
+				loc.GetN("%d page read.", "%d pages read.", 101))
+			assert.Equal(t, "%d страниц прочитано.",
+				loc.GetN("%d page read.", "%d pages read.", 12))
+			assert.Equal(t, "%d pages",
+				loc.GetN("%d page read.", "%d pages", 12))
+			str, ok := loc.Lookup("Missed", "", nil, -1)
+			assert.False(t, ok)
+			assert.Equal(t, "Missed", str)
+		})
+	}
+}
+
+func TestMOLocaleMatchMOFile(t *testing.T) {
+	t.Parallel()
+
+	mo := &pogo.MOFile{
+		Header: pogo.Header{Language: "ru"},
+		Entries: map[string][]string{
+			"Empty":                     {""},
+			"Open":                      {"Открыть"},
+			"\x04Close":                 {"Закрыть"},
+			"menu\x04Open":              {"Открыть меню"},
+			"%d file\x00%d files":       {"%d файл", "%d файла", "%d файлов"},
+			"menu\x04%d tab\x00%d tabs": {"%d вкладка", "%d вкладки"},
+		},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, mo.Write(buf, pogo.WithWriteCharset("KOI8-R")))
+	loc, err := pogo.OpenMOLocaleBytes(buf.Bytes())
+	require.NoError(t, err)
+
+	for _, msg := range [...]string{"Empty", "Open", "Close", "Missed"} {
+		assert.Equal(t, mo.Get(msg), loc.Get(msg), msg)
+		assert.Equal(t, mo.GetCtxt(msg, ""), loc.GetCtxt(msg, ""), msg)
+		assert.Equal(t, mo.GetCtxt(msg, "menu"), loc.GetCtxt(msg, "menu"), msg)
+	}
+	for n := 0; n < 30; n++ {
+		assert.Equal(t, mo.GetN("%d file", "%d files", n), loc.GetN("%d file", "%d files", n))
+		assert.Equal(t, mo.GetCtxtN("%d tab", "%d tabs", "menu", n), loc.GetCtxtN("%d tab", "%d tabs", "menu", n))
+	}
+}
+
+func TestMOLocaleErrors(t *testing.T) {
+	t.Parallel()
+
+	data := golden.Get(t, "example.mo")
+	cases := [...]struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", nil, "ru.mo:offset 0: unexpected end of file: invalid mo file"},
+		{"bad magic", []byte("not a mo file at all, really"), "ru.mo:offset 0: magic number mistmatch: invalid mo file"},
+		{"truncated", data[:100], "ru.mo:offset 24: bad hashing table offset: invalid mo file"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := pogo.OpenMOLocaleBytes(c.data, pogo.WithMOFilename("ru.mo"))
+			assert.EqualError(t, err, c.err)
+			perr, ok := errors.Cause(err).(*pogo.ParseError)
+			require.True(t, ok)
+			assert.Equal(t, pogo.KindInvalidMO, perr.Kind)
+		})
+	}
+}
@@ -1,14 +1,24 @@
 package pogo
 
 import (
+	"bytes"
 	"encoding/binary"
 	"io"
+	"io/ioutil"
+	"math"
 
 	"github.com/pkg/errors"
 )
 
 const (
+	// DefaultMaxMOSize is a default limit of mo file size
+	DefaultMaxMOSize = 256 << 20
+	// DefaultMaxMOStringSize is a default limit of mo file string size
+	DefaultMaxMOStringSize = DefaultMaxLineSize
+
 	magic uint32 = 0x950412de
+	// magicSwapped is a magic of big-endian file read as little-endian
+	magicSwapped uint32 = 0xde120495
 )
 
 var errMOFile = errors.New("invalid mo file")
@@ -17,25 +27,100 @@ type position struct {
 	Length, Offset uint32
 }
 
+// MOReaderOption customize reading of mo file
+type MOReaderOption func(*moReader)
+
+// WithMOFilename set name of source to be reported in parse errors
+func WithMOFilename(name string) MOReaderOption {
+	return func(mr *moReader) {
+		mr.filename = name
+	}
+}
+
+// WithMaxMOSize limit size of mo file (DefaultMaxMOSize by default)
+//
+// File is not read beyond the limit, longer file, tables and strings out of
+// limit are reported as invalid mo file.
+func WithMaxMOSize(size int64) MOReaderOption {
+	return func(mr *moReader) {
+		mr.maxSize = uint64(size)
+		if size < 0 || size > math.MaxUint32 {
+			mr.maxSize = math.MaxUint32
+		}
+	}
+}
+
+// WithMaxMOStringSize limit length of each string of mo file
+//
+// By default DefaultMaxMOStringSize is used.
+func WithMaxMOStringSize(size int) MOReaderOption {
+	return func(mr *moReader) {
+		mr.maxStringSize = uint64(size)
+	}
+}
+
 type moReader struct {
-	r      io.Reader
-	offset uint32
-
-	N            uint32     // number of strings
-	O            uint32     // offset of table with original strings
-	T            uint32     // offset of table with translation strings
-	S            uint32     // size of hashing table
-	H            uint32     // offset of hashing table
+	r             io.Reader
+	offset        uint32
+	filename      string
+	maxSize       uint64
+	maxStringSize uint64
+	order         binary.ByteOrder
+	revision      uint32
+	// data of file, it is read at once to random access
+	data []byte
+
+	N            uint32 // number of strings
+	O            uint32 // offset of table with original strings
+	T            uint32 // offset of table with translation strings
+	S            uint32 // size of hashing table
+	H            uint32 // offset of hashing table
+	Sysdep       sysdepTables
 	OTable       []position // length and offset of original strings
 	TTable       []position // length and offset of translated strings
 	Originals    []string   // original strings
 	Translations []string   // translated strings
 }
 
+func newMOReader(r io.Reader, opts []MOReaderOption) *moReader {
+	mr := &moReader{
+		r:             r,
+		maxSize:       DefaultMaxMOSize,
+		maxStringSize: DefaultMaxMOStringSize,
+	}
+	for _, opt := range opts {
+		opt(mr)
+	}
+
+	return mr
+}
+
 func (mr *moReader) Read() error {
 	err := recoverHandledError(mr.mustRead)
+	if err == nil {
+		return nil
+	}
 
-	return errors.Wrapf(err, "at %d", mr.offset)
+	return moParseError(mr.filename, mr.offset, err)
+}
+
+// moParseError classify error of reading mo file
+func moParseError(filename string, offset uint32, err error) *ParseError {
+	perr := &ParseError{
+		Filename: filename,
+		Offset:   int64(offset),
+		Kind:     KindIO,
+		Err:      err,
+	}
+	switch errors.Cause(err) {
+	case errMOFile:
+		perr.Kind = KindInvalidMO
+	case io.EOF, io.ErrUnexpectedEOF:
+		perr.Kind = KindInvalidMO
+		perr.Err = errors.Wrap(errMOFile, "unexpected end of file")
+	}
+
+	return perr
 }
 
 func (mr *moReader) mustRead() {
@@ -43,96 +128,156 @@ func (mr *moReader) mustRead() {
 		first, revision uint32
 	)
 
+	mr.order = binary.LittleEndian
 	mr.mustReadUint32(&first)
-	if first != magic {
+	switch first {
+	case magic:
+	case magicSwapped:
+		mr.order = binary.BigEndian
+	default:
 		panic(errors.Wrap(errMOFile, "magic number mistmatch"))
 	}
 	mr.mustReadUint32(&revision)
-	if revision != 0 {
+	if revision != 0 && revision != 1 {
 		panic(errors.Wrapf(errMOFile, "unsupported format revision %d", revision))
 	}
+	mr.revision = revision
+	mr.mustReadAll()
 
 	mr.mustReadTables()
 
-	mr.Originals = mr.mustReadStrings(mr.OTable)
-	mr.Translations = mr.mustReadStrings(mr.TTable)
+	// strings share memory of single copy of data, so overlapped strings
+	// of crafted file could not blow it up
+	text := string(mr.data)
+	mr.Originals = mr.mustReadStrings(text, mr.OTable)
+	mr.Translations = mr.mustReadStrings(text, mr.TTable)
+	if mr.Sysdep.N > 0 {
+		originals, translations, err := mr.Sysdep.read(moTables{
+			src:           bytesSource(mr.data),
+			order:         mr.order,
+			maxStringSize: mr.maxStringSize,
+		})
+		if err != nil {
+			panic(err)
+		}
+		mr.Originals = append(mr.Originals, originals...)
+		mr.Translations = append(mr.Translations, translations...)
+	}
+}
+
+// mustReadAll rest of source to data, reading goes on from data
+//
+// Tables and strings may be placed anywhere in file, so it is read at once
+// to random access.
+func (mr *moReader) mustReadAll() {
+	limit := int64(mr.maxSize) - int64(mr.offset)
+	rest, err := ioutil.ReadAll(io.LimitReader(mr.r, limit+1))
+	if err != nil {
+		panic(errors.WithStack(err))
+	}
+	if int64(len(rest)) > limit {
+		panic(errors.Wrap(errMOFile, "file is out of size limit"))
+	}
+	mr.data = make([]byte, mr.offset, int(mr.offset)+len(rest))
+	mr.order.PutUint32(mr.data, magic)
+	mr.order.PutUint32(mr.data[4:], mr.revision)
+	mr.data = append(mr.data, rest...)
+	mr.r = bytes.NewReader(rest)
 }
 
 func (mr *moReader) mustReadTables() {
 	mr.mustReadUint32(&mr.N)
-	if mr.N == 0 {
+	if mr.N == 0 && mr.revision == 0 {
 		return
 	}
+	tableSize := uint64(mr.N) * 8
 	mr.mustReadUint32(&mr.O)
 	if mr.O < 28 {
 		panic(errors.Wrap(errMOFile, "bad original table offset"))
 	}
+	mr.mustFit(uint64(mr.O), tableSize, "original table")
 	mr.mustReadUint32(&mr.T)
-	if mr.T < mr.O+mr.N*8 {
+	if uint64(mr.T) < uint64(mr.O)+tableSize {
 		panic(errors.Wrap(errMOFile, "bad translation table offset"))
 	}
+	mr.mustFit(uint64(mr.T), tableSize, "translation table")
 	mr.mustReadUint32(&mr.S)
 	mr.mustReadUint32(&mr.H)
-	if mr.S > 0 && mr.H < mr.T+mr.N*8 {
+	if mr.S > 0 && uint64(mr.H) < uint64(mr.T)+tableSize {
 		panic(errors.Wrap(errMOFile, "bad hashing table offset"))
 	}
-	mr.mustSeek(mr.O)
-	mr.OTable = mr.mustReadPositionTable(mr.H + mr.S*4)
-	last := mr.OTable[mr.N-1]
-	mr.TTable = mr.mustReadPositionTable(last.Offset + last.Length + 1)
+	if mr.S > 0 {
+		mr.mustFit(uint64(mr.H), uint64(mr.S)*4, "hashing table")
+	}
+	if mr.revision == 1 {
+		mr.mustReadUint32(&mr.Sysdep.SegmentsN)
+		mr.mustReadUint32(&mr.Sysdep.SegmentsOffset)
+		mr.mustReadUint32(&mr.Sysdep.N)
+		mr.mustReadUint32(&mr.Sysdep.O)
+		mr.mustReadUint32(&mr.Sysdep.T)
+	}
+	if mr.N == 0 {
+		return
+	}
+	mr.OTable = mr.mustReadPositionTable(mr.O)
+	mr.TTable = mr.mustReadPositionTable(mr.T)
 }
 
+// mustReadPositionTable at offset of data
+//
+// Only bounds and size of strings are checked, they may be placed anywhere
+// in file.
 func (mr *moReader) mustReadPositionTable(offset uint32) []position {
+	mr.offset = offset
+	if uint64(offset)+uint64(mr.N)*8 > uint64(len(mr.data)) {
+		panic(errors.WithStack(io.ErrUnexpectedEOF))
+	}
 	table := make([]position, mr.N)
-	for i := 0; i < int(mr.N); i++ {
-		mr.mustReadPosition(&table[i])
-		if table[i].Offset < offset {
-			panic(errors.Wrap(errMOFile, "bad offset in table"))
+	for i := range table {
+		cell := mr.data[mr.offset:]
+		pos := position{
+			Length: mr.order.Uint32(cell),
+			Offset: mr.order.Uint32(cell[4:]),
+		}
+		mr.offset += 8
+		if uint64(pos.Length) > mr.maxStringSize {
+			panic(errors.Wrap(errMOFile, "string is out of size limit"))
 		}
-		offset = table[i].Offset + table[i].Length + 1
+		end := uint64(pos.Offset) + uint64(pos.Length) + 1
+		mr.mustFit(uint64(pos.Offset), uint64(pos.Length)+1, "string")
+		if end > uint64(len(mr.data)) {
+			panic(errors.WithStack(io.ErrUnexpectedEOF))
+		}
+		table[i] = pos
 	}
 
 	return table
 }
 
-func (mr *moReader) mustReadPosition(pos *position) {
-	if err := binary.Read(mr.r, binary.LittleEndian, pos); err != nil {
-		panic(errors.WithStack(err))
+// mustFit panics if region is out of size limit
+func (mr *moReader) mustFit(offset, size uint64, what string) {
+	if offset+size > mr.maxSize {
+		panic(errors.Wrapf(errMOFile, "%s is out of size limit", what))
 	}
-	mr.offset += 8
 }
 
-func (mr *moReader) mustReadStrings(table []position) []string {
+func (mr *moReader) mustReadStrings(text string, table []position) []string {
 	res := make([]string, len(table))
 	for i, pos := range table {
-		mr.mustSeek(pos.Offset)
-		s := make([]byte, pos.Length+1)
-		n, err := mr.r.Read(s)
-		mr.offset += uint32(n)
-		if err != nil {
-			panic(errors.WithStack(err))
-		}
-		if s[pos.Length] != 0 {
+		end := pos.Offset + pos.Length
+		if text[end] != 0 {
+			mr.offset = end
 			panic(errors.Wrap(errMOFile, "expected null byte"))
 		}
-		res[i] = string(s[:pos.Length])
+		res[i] = text[pos.Offset:end]
 	}
 
 	return res
 }
 
 func (mr *moReader) mustReadUint32(x *uint32) {
-	if err := binary.Read(mr.r, binary.LittleEndian, x); err != nil {
+	if err := binary.Read(mr.r, mr.order, x); err != nil {
 		panic(errors.WithStack(err))
 	}
 	mr.offset += 4
 }
-
-func (mr *moReader) mustSeek(offset uint32) {
-	buf := make([]byte, offset-mr.offset)
-	n, err := mr.r.Read(buf)
-	mr.offset += uint32(n)
-	if err != nil {
-		panic(errors.WithStack(err))
-	}
-}
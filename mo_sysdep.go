@@ -0,0 +1,144 @@
+package pogo
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sysdepEnd terminates segments of system dependent string
+const sysdepEnd uint32 = 0xffffffff
+
+// sysdepTables describe system dependent strings of mo file revision 1
+//
+// Such strings are stored as static parts interleaved with references to
+// segments like "PRIu64", which are expanded for the host on reading.
+type sysdepTables struct {
+	SegmentsN      uint32 // number of system dependent segments
+	SegmentsOffset uint32 // offset of table with segments
+	N              uint32 // number of system dependent strings
+	O              uint32 // offset of table with original strings
+	T              uint32 // offset of table with translation strings
+}
+
+// read and expand system dependent strings
+//
+// Strings with segments unknown for the host are skipped as gettext do it.
+func (tables sysdepTables) read(mt moTables) (originals, translations []string, err error) {
+	if !mt.fits(uint64(tables.SegmentsOffset), uint64(tables.SegmentsN)*8) ||
+		!mt.fits(uint64(tables.O), uint64(tables.N)*4) ||
+		!mt.fits(uint64(tables.T), uint64(tables.N)*4) {
+		return nil, nil, errors.Wrap(errMOFile, "bad system dependent table offset")
+	}
+	// segments and strings of valid file do not overlap, so they could not
+	// be longer than file in total, it prevents blowup on crafted files
+	budget := mt.src.size()
+	segments := make([]string, tables.SegmentsN)
+	for i := range segments {
+		pos, err := mt.position(tables.SegmentsOffset, uint32(i))
+		if err != nil {
+			return nil, nil, err
+		}
+		cost := 8 + uint64(pos.Length)
+		if cost > budget {
+			return nil, nil, errors.Wrap(errMOFile, "system dependent segments overlap")
+		}
+		budget -= cost
+		name, err := mt.src.slice(pos.Offset, pos.Length)
+		if err != nil {
+			return nil, nil, err
+		}
+		segments[i] = string(name)
+	}
+	originals = make([]string, 0, tables.N)
+	translations = make([]string, 0, tables.N)
+	for i := uint32(0); i < tables.N; i++ {
+		orig, ok, err := tables.expand(mt, tables.O+i*4, segments, false, &budget)
+		if err != nil {
+			return nil, nil, err
+		}
+		trans, transOK, err := tables.expand(mt, tables.T+i*4, segments, true, &budget)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok && transOK {
+			originals = append(originals, orig)
+			translations = append(translations, trans)
+		}
+	}
+
+	return originals, translations, nil
+}
+
+// expand string referred by table cell at offset
+func (tables sysdepTables) expand(
+	mt moTables, offset uint32, segments []string, translation bool, budget *uint64,
+) (string, bool, error) {
+	pair, err := mt.uint32At(offset)
+	if err != nil {
+		return "", false, err
+	}
+	static, err := mt.uint32At(pair)
+	if err != nil {
+		return "", false, err
+	}
+	known := true
+	res := &strings.Builder{}
+	for pair += 4; ; pair += 8 {
+		size, err := mt.uint32At(pair)
+		if err != nil {
+			return "", false, err
+		}
+		ref, err := mt.uint32At(pair + 4)
+		if err != nil {
+			return "", false, err
+		}
+		cost := 8 + uint64(size)
+		if cost > *budget {
+			return "", false, errors.Wrap(errMOFile, "system dependent strings overlap")
+		}
+		*budget -= cost
+		part, err := mt.src.slice(static, size)
+		if err != nil {
+			return "", false, err
+		}
+		res.Write(part)
+		static += size
+		if ref == sysdepEnd {
+			break
+		}
+		if ref >= uint32(len(segments)) {
+			return "", false, errors.Wrap(errMOFile, "bad system dependent segment")
+		}
+		value, ok := sysdepSegmentValue(segments[ref], translation)
+		known = known && ok
+		res.WriteString(value)
+	}
+
+	return res.String(), known, nil
+}
+
+// sysdepSegmentValue expand segment as C library of 64-bit host do it
+//
+// Format macros of <inttypes.h> are expanded as in glibc, "I" flag of digits
+// is kept in translations only.
+func sysdepSegmentValue(name string, translation bool) (string, bool) {
+	if name == "I" {
+		if translation {
+			return "I", true
+		}
+		return "", true
+	}
+	if len(name) < 5 || !strings.HasPrefix(name, "PRI") || !strings.ContainsRune("diouxX", rune(name[3])) {
+		return "", false
+	}
+	conv := name[3:4]
+	switch name[4:] {
+	case "8", "16", "32", "LEAST8", "LEAST16", "LEAST32", "FAST8":
+		return conv, true
+	case "64", "LEAST64", "FAST16", "FAST32", "FAST64", "MAX", "PTR":
+		return "l" + conv, true
+	}
+
+	return "", false
+}
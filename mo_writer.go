@@ -9,8 +9,29 @@ import (
 	"github.com/pkg/errors"
 )
 
+// WriteOption customize writing of mo file
+type WriteOption func(*moWriter)
+
+// WithWriteCharset encode strings to charset and declare it in header
+//
+// By default charset declared in header is used.
+func WithWriteCharset(name string) WriteOption {
+	return func(mw *moWriter) {
+		mw.charset = name
+	}
+}
+
+// WithByteOrder write file in byte order (little-endian by default)
+func WithByteOrder(order binary.ByteOrder) WriteOption {
+	return func(mw *moWriter) {
+		mw.order = order
+	}
+}
+
 type moWriter struct {
-	w io.Writer
+	w       io.Writer
+	charset string
+	order   binary.ByteOrder
 
 	file         *MOFile
 	Originals    []string
@@ -32,35 +53,119 @@ func (mw *moWriter) mustWrite() {
 	mw.mustWriteUint32(O)
 	T := O + N*8 // offset of translations table
 	mw.mustWriteUint32(T)
-	S := uint32(0) // size of hashing table (not implemented)
+	hashTable := makeHashTable(mw.Originals)
+	S := uint32(len(hashTable)) // size of hashing table
 	mw.mustWriteUint32(S)
 	H := T + N*8 // offset of hashing table
 	mw.mustWriteUint32(H)
-	offset := H
+	offset := H + S*4
 	offset = mw.mustWritePositions(offset, mw.Originals)
 	mw.mustWritePositions(offset, mw.Translations)
+	for _, x := range hashTable {
+		mw.mustWriteUint32(x)
+	}
 	mw.mustWriteStrings(mw.Originals)
 	mw.mustWriteStrings(mw.Translations)
 }
 
 func (mw *moWriter) prepareResources() {
+	header := mw.file.Header
+	var cs *charset
+	if mw.charset == "" {
+		cs = mustCharsetOfContentType(header.ContentType)
+	} else {
+		cs = mustLookupCharset(mw.charset)
+		header.ContentType = withCharset(header.ContentType, mw.charset)
+	}
+	mustEncode := func(text string) string {
+		res, err := cs.encode(text)
+		if err != nil {
+			panic(err)
+		}
+		return res
+	}
+
+	translations := make(map[string]string, len(mw.file.Entries)+1)
+	translations[""] = mustEncode(header.ToEntry().MsgStr) // header entry
 	mw.Originals = make([]string, 1, len(mw.file.Entries)+1)
-	mw.Originals[0] = "" // header entry
-	for id := range mw.file.Entries {
+	for id, forms := range mw.file.Entries {
 		if id != "" {
-			mw.Originals = append(mw.Originals, id)
+			encoded := mustEncode(id)
+			mw.Originals = append(mw.Originals, encoded)
+			translations[encoded] = mustEncode(strings.Join(forms, pluralSep))
 		}
 	}
 	sort.Strings(mw.Originals)
-	header := mw.file.Header.ToEntry()
 	mw.Translations = make([]string, len(mw.Originals))
 	for i, id := range mw.Originals {
-		if id == "" {
-			mw.Translations[i] = header.MsgStr
-		} else {
-			mw.Translations[i] = strings.Join(mw.file.Entries[id], pluralSep)
+		mw.Translations[i] = translations[id]
+	}
+}
+
+// makeHashTable build open addressing hash table as msgfmt do it
+//
+// Table holds one based indexes of originals, zero is an empty cell.
+func makeHashTable(originals []string) []uint32 {
+	size := nextPrime(uint32(len(originals)) * 4 / 3)
+	if size <= 2 {
+		size = 3
+	}
+	table := make([]uint32, size)
+	for i, id := range originals {
+		hash := hashPJW(id)
+		idx := hash % size
+		incr := 1 + hash%(size-2)
+		for table[idx] != 0 {
+			if idx >= size-incr {
+				idx -= size - incr
+			} else {
+				idx += incr
+			}
+		}
+		table[idx] = uint32(i) + 1
+	}
+
+	return table
+}
+
+// hashPJW of original up to plural form as gettext do it
+func hashPJW(id string) uint32 {
+	var hash uint32
+	for i := 0; i < len(id) && id[i] != 0; i++ {
+		hash = hash<<4 + uint32(id[i])
+		if g := hash & 0xf0000000; g != 0 {
+			hash ^= g >> 24
+			hash ^= g
 		}
 	}
+
+	return hash
+}
+
+// nextPrime returns the least odd number greater or equal to seed which
+// isPrime consider as prime
+func nextPrime(seed uint32) uint32 {
+	seed |= 1
+	for !isPrime(seed) {
+		seed += 2
+	}
+
+	return seed
+}
+
+// isPrime check odd x as is_prime of msgfmt do it
+//
+// It is not exact for small numbers (3 is not a prime), but table size must
+// match msgfmt output.
+func isPrime(x uint32) bool {
+	div, sq := uint32(3), uint32(9)
+	for sq < x && x%div != 0 {
+		div++
+		sq += 4 * div
+		div++
+	}
+
+	return x%div != 0
 }
 
 func (mw *moWriter) mustWritePositions(offset uint32, data []string) uint32 {
@@ -75,7 +180,7 @@ func (mw *moWriter) mustWritePositions(offset uint32, data []string) uint32 {
 }
 
 func (mw *moWriter) mustWriteUint32(x uint32) {
-	if err := binary.Write(mw.w, binary.LittleEndian, x); err != nil {
+	if err := binary.Write(mw.w, mw.order, x); err != nil {
 		panic(errors.WithStack(err))
 	}
 }
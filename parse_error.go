@@ -0,0 +1,82 @@
+package pogo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseErrorKind classify parse errors
+type ParseErrorKind string
+
+// Kinds of parse errors
+const (
+	// KindNoStarter is a line which is not matched any starter
+	KindNoStarter ParseErrorKind = "no starter"
+	// KindBadQuotes is an invalid quoted string
+	KindBadQuotes ParseErrorKind = "bad quotes"
+	// KindDuplicate is a repeated block of entry
+	KindDuplicate ParseErrorKind = "duplicate block"
+	// KindMixedObsolete is an entry with obsolete and not obsolete blocks
+	KindMixedObsolete ParseErrorKind = "mixed obsolete"
+	// KindBadPluralIndex is an invalid index of msgstr[N]
+	KindBadPluralIndex ParseErrorKind = "bad plural index"
+	// KindInvalidMO is a broken structure of mo file
+	KindInvalidMO ParseErrorKind = "invalid mo file"
+	// KindIO is an error of underlying reader
+	KindIO ParseErrorKind = "io"
+)
+
+// ParseError describe where and why source could not be parsed
+//
+// PO errors have one based Line and Column, MO errors have byte Offset.
+// Use errors.Cause to get ParseError from returned error.
+type ParseError struct {
+	Filename string
+	Line     int
+	Column   int
+	Offset   int64
+	// Text of the offending line
+	Text string
+	Kind ParseErrorKind
+	Err  error
+}
+
+// Error implements error
+func (err *ParseError) Error() string {
+	pos := fmt.Sprintf("%d:%d", err.Line, err.Column)
+	if err.Line == 0 {
+		pos = fmt.Sprintf("offset %d", err.Offset)
+	}
+	if err.Filename != "" {
+		pos = err.Filename + ":" + pos
+	}
+
+	return fmt.Sprintf("%s: %s", pos, err.Err)
+}
+
+// Unwrap returns underlying error
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// ParseErrors is a list of errors found by parsing in recovery mode
+type ParseErrors []*ParseError
+
+// Error implements error
+func (errs ParseErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i := range errs {
+		lines[i] = errs[i].Error()
+	}
+
+	return fmt.Sprintf("%d parse errors:\n%s", len(errs), strings.Join(lines, "\n"))
+}
+
+// errorOrNil returns errs as error if there are any
+func (errs ParseErrors) errorOrNil() error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
@@ -2,9 +2,6 @@ package pogo
 
 import (
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,114 +9,100 @@ import (
 	"github.com/pkg/errors"
 )
 
-var (
-	pluralAllRE  = regexp.MustCompile(`^nplurals\s*=\s*(\d+)\s*;\s*plural\s*=(.+);$`)
-	pluralRuleRE = regexp.MustCompile(`\s*([^?]+)\?\s*(\d+)\s*:(\s*(\d+)\s*)?`)
+var pluralAllRE = regexp.MustCompile(`^\s*nplurals\s*=\s*(\d+)\s*;\s*plural\s*=(.+?);?\s*$`)
+
+const (
+	// pluralCheckLimit is a range of n to check plural expression results
+	pluralCheckLimit = 1000
+	// maxPluralSourceSize is a limit of plural rules source length, the
+	// longest of known rules is about 200 bytes
+	maxPluralSourceSize = 1024
+	// maxPluralDepth is a limit of plural expression nesting
+	maxPluralDepth = 64
+	// maxPluralForms is a limit of nplurals
+	maxPluralForms = 64
 )
 
-// PluralRules represent list of rules to evaluate which form should be used
-type PluralRules []PluralRule
+// PluralRules represent plural expression to evaluate which form should be used
+//
+// Zero value is a single form rule "nplurals=1; plural=0;".
+type PluralRules struct {
+	n      int
+	expr   pluralExpr
+	source string
+}
 
 // Eval evaluate rules to find which form should be used
+//
+// Negative n is treated as its absolute value. Results out of range fall back
+// to the first form as gettext do.
 func (rules PluralRules) Eval(n int) int {
-	if len(rules) == 1 {
-		if rules[0].Check(n) {
-			return 1
-		}
-
+	if rules.expr == nil {
 		return 0
 	}
-	for i := range rules {
-		if rules[i].Check(n) {
-			return i
-		}
+	if n < 0 {
+		n = -n
+	}
+	k := rules.expr.eval(uint64(n))
+	if k >= uint64(rules.Len()) {
+		return 0
 	}
 
-	return len(rules)
+	return int(k)
 }
 
-// Len is a number of rules
+// Len is a number of forms
 func (rules PluralRules) Len() int {
-	return len(rules) + 1
+	if rules.n == 0 {
+		return 1
+	}
+
+	return rules.n
 }
 
 // String implements fmt.Stringer
 func (rules PluralRules) String() string {
-	res := &strings.Builder{}
-	_, _ = fmt.Fprintf(res, "nplurals=%d; plural=", rules.Len())
-	if len(rules) == 1 {
-		_, _ = fmt.Fprintf(res, "%s;", rules[0])
-	} else {
-		for i := range rules {
-			_, _ = fmt.Fprintf(res, "%s ? %d : ", rules[i], i)
-		}
-		_, _ = fmt.Fprintf(res, "%d;", len(rules))
+	if rules.expr == nil {
+		return "nplurals=1; plural=0;"
 	}
 
-	return res.String()
+	return fmt.Sprintf("nplurals=%d; plural=%s;", rules.n, rules.source)
 }
 
 // ParsePluralRules from po format
+//
+// Plural expression is a C expression of gettext grammar with a single
+// variable n: ternary operator, logical, comparison and arithmetic operators
+// with unsigned long semantics. Division by zero gives zero.
 func ParsePluralRules(source string) (PluralRules, error) {
+	if len(source) > maxPluralSourceSize {
+		return PluralRules{}, errors.Errorf("source is longer than %d bytes", maxPluralSourceSize)
+	}
 	sub := pluralAllRE.FindStringSubmatch(source)
 	if len(sub) != 3 {
-		return nil, errors.New("invalid source format")
+		return PluralRules{}, errors.New("invalid source format")
 	}
-	n, _ := strconv.Atoi(sub[1])
-	switch n {
-	case 0:
-
-		return nil, errors.New("nplurals shouldn't be zero")
-
-	case 1:
-		k, err := strconv.Atoi(strings.TrimSpace(sub[2]))
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		if k != 0 {
-			return nil, errors.Errorf("unexpected choice %d, expected 0", k)
-		}
-
-		return nil, nil
-
-	case 2:
-		rule, err := ParsePluralRule(sub[2])
-		if err != nil {
-			return nil, err
-		}
-
-		return PluralRules{rule}, nil
-
-	default:
-
-		return parsePluralRules(sub[2], n)
+	n, err := strconv.Atoi(sub[1])
+	if err != nil {
+		return PluralRules{}, errors.WithStack(err)
 	}
-}
-
-func parsePluralRules(source string, n int) (PluralRules, error) {
-	subs := pluralRuleRE.FindAllStringSubmatch(source, -1)
-	if len(subs) != n-1 {
-		return nil, errors.New("rules count missmatch")
+	if n == 0 {
+		return PluralRules{}, errors.New("nplurals shouldn't be zero")
 	}
-	res := make(PluralRules, n-1)
-	for i, sub := range subs {
-		k, _ := strconv.Atoi(sub[2])
-		if k != i {
-			return nil, errors.Errorf("unexpected choice %d, expected %d", k, i)
-		}
-		if i == n-2 {
-			o, _ := strconv.Atoi(sub[4])
-			if o != n-1 {
-				return nil, errors.Errorf("unexpected choice %d, expected %d", o, n-1)
-			}
-		}
-		rule, err := ParsePluralRule(strings.TrimSpace(sub[1]))
-		if err != nil {
-			return nil, err
+	if n > maxPluralForms {
+		return PluralRules{}, errors.Errorf("nplurals shouldn't be greater than %d", maxPluralForms)
+	}
+	rules := PluralRules{n: n, source: strings.TrimSpace(sub[2])}
+	if rules.expr, err = parsePluralExpr(rules.source); err != nil {
+		return PluralRules{}, err
+	}
+	for i := uint64(0); i < pluralCheckLimit; i++ {
+		if k := rules.expr.eval(i); k >= uint64(n) {
+			return PluralRules{}, errors.Errorf("unexpected choice %d for n = %d, expected less than %d", k, i, n)
 		}
-		res[i] = rule
 	}
-	return res, nil
+
+	return rules, nil
 }
 
 // PluralRule is a condition to choose variant
@@ -130,198 +113,277 @@ type PluralRule interface {
 
 // ParsePluralRule from source
 func ParsePluralRule(source string) (PluralRule, error) {
-	return ruleBuilder{source}.Build()
-}
+	if len(source) > maxPluralSourceSize {
+		return nil, errors.Errorf("source is longer than %d bytes", maxPluralSourceSize)
+	}
+	expr, err := parsePluralExpr(source)
+	if err != nil {
+		return nil, err
+	}
 
-type evaluer struct {
-	source string
-	Eval   func(int) int
+	return pluralCondition{expr, source}, nil
 }
 
-func (e evaluer) String() string {
-	return e.source
+type pluralCondition struct {
+	expr   pluralExpr
+	source string
 }
 
-func number(n int) evaluer { return evaluer{strconv.Itoa(n), func(x int) int { return n }} }
-func equiv() evaluer       { return evaluer{"n", func(x int) int { return x }} }
-func mod(a, b evaluer) evaluer {
-	return evaluer{
-		fmt.Sprintf("%s%%%s", a, b),
-		func(x int) int { return a.Eval(x) % b.Eval(x) },
+func (cond pluralCondition) Check(n int) bool {
+	if n < 0 {
+		n = -n
 	}
-}
 
-type parenthes struct {
-	rule PluralRule
+	return cond.expr.eval(uint64(n)) != 0
 }
 
-func (p parenthes) Check(n int) bool {
-	return p.rule.Check(n)
+func (cond pluralCondition) String() string {
+	return cond.source
 }
 
-func (p parenthes) String() string {
-	return fmt.Sprintf("(%s)", p.rule)
+type pluralExpr interface {
+	eval(n uint64) uint64
 }
 
-type checker struct {
-	op    string
-	a, b  fmt.Stringer
-	check func(int) bool
-}
+type pluralVar struct{}
 
-func (cr checker) String() string {
-	return fmt.Sprintf("%s %s %s", cr.a, cr.op, cr.b)
+func (pluralVar) eval(n uint64) uint64 {
+	return n
 }
 
-func (cr checker) Check(n int) bool {
-	return cr.check(n)
+type pluralConst uint64
+
+func (c pluralConst) eval(uint64) uint64 {
+	return uint64(c)
 }
 
-func eql(a, b evaluer) PluralRule {
-	return checker{
-		"==", a, b,
-		func(n int) bool { return a.Eval(n) == b.Eval(n) },
-	}
+type pluralNot struct {
+	x pluralExpr
 }
 
-func neq(a, b evaluer) PluralRule {
-	return checker{
-		"!=", a, b,
-		func(n int) bool { return a.Eval(n) != b.Eval(n) },
-	}
+func (not pluralNot) eval(n uint64) uint64 {
+	return boolToUint(not.x.eval(n) == 0)
 }
 
-func gtr(a, b evaluer) PluralRule {
-	return checker{
-		">", a, b,
-		func(n int) bool { return a.Eval(n) > b.Eval(n) },
-	}
+type pluralBinary struct {
+	op   string
+	x, y pluralExpr
 }
 
-func geq(a, b evaluer) PluralRule {
-	return checker{
-		">=", a, b,
-		func(n int) bool { return a.Eval(n) >= b.Eval(n) },
+func (bin pluralBinary) eval(n uint64) uint64 {
+	x := bin.x.eval(n)
+	// short circuit as in C
+	switch bin.op {
+	case "&&":
+		return boolToUint(x != 0 && bin.y.eval(n) != 0)
+	case "||":
+		return boolToUint(x != 0 || bin.y.eval(n) != 0)
+	}
+	y := bin.y.eval(n)
+	switch bin.op {
+	case "==":
+		return boolToUint(x == y)
+	case "!=":
+		return boolToUint(x != y)
+	case "<":
+		return boolToUint(x < y)
+	case "<=":
+		return boolToUint(x <= y)
+	case ">":
+		return boolToUint(x > y)
+	case ">=":
+		return boolToUint(x >= y)
+	case "+":
+		return x + y
+	case "-":
+		return x - y
+	case "*":
+		return x * y
+	case "/":
+		if y == 0 {
+			return 0
+		}
+		return x / y
+	case "%":
+		if y == 0 {
+			return 0
+		}
+		return x % y
 	}
+
+	return 0
 }
 
-func lss(a, b evaluer) PluralRule {
-	return checker{
-		"<", a, b,
-		func(n int) bool { return a.Eval(n) < b.Eval(n) },
-	}
+type pluralTernary struct {
+	cond, then, other pluralExpr
 }
 
-func leq(a, b evaluer) PluralRule {
-	return checker{
-		"<=", a, b,
-		func(n int) bool { return a.Eval(n) <= b.Eval(n) },
+func (ter pluralTernary) eval(n uint64) uint64 {
+	if ter.cond.eval(n) != 0 {
+		return ter.then.eval(n)
 	}
+
+	return ter.other.eval(n)
 }
 
-func and(a, b PluralRule) PluralRule {
-	return checker{
-		"&&", a, b,
-		func(n int) bool { return a.Check(n) && b.Check(n) },
+func boolToUint(b bool) uint64 {
+	if b {
+		return 1
 	}
+
+	return 0
 }
 
-func or(a, b PluralRule) PluralRule {
-	return checker{
-		"||", a, b,
-		func(n int) bool { return a.Check(n) || b.Check(n) },
-	}
+// pluralPrecedence of binary operators, greater binds tighter
+var pluralPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
 }
 
-type ruleBuilder struct {
+// pluralParser is a recursive descent parser of plural expression
+type pluralParser struct {
 	source string
+	pos    int
+	// current token and its position
+	tok    string
+	tokPos int
+	// depth of nested expressions
+	depth int
 }
 
-func (cb ruleBuilder) Build() (PluralRule, error) {
-	expr, err := parser.ParseExpr(cb.source)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-
-	var ch PluralRule
-	err = recoverHandledError(func() {
-		ch = cb.processExpression(expr)
+func parsePluralExpr(source string) (pluralExpr, error) {
+	p := &pluralParser{source: source}
+	var expr pluralExpr
+	err := recoverHandledError(func() {
+		p.next()
+		expr = p.parseTernary()
+		if p.tok != "" {
+			p.fail("unexpected '%s'", p.tok)
+		}
 	})
 
-	return ch, err
+	return expr, err
 }
 
-func (cb ruleBuilder) processExpression(expr ast.Expr) PluralRule {
-	switch te := expr.(type) {
-	case *ast.ParenExpr:
-		return parenthes{cb.processExpression(te.X)}
+func (p *pluralParser) fail(format string, args ...interface{}) {
+	panic(errors.Errorf("1:%d: %s", p.tokPos+1, fmt.Sprintf(format, args...)))
+}
 
-	case *ast.BinaryExpr:
-		return cb.processComparsion(te)
+// next read token into p.tok, empty token means end of source
+func (p *pluralParser) next() {
+	for p.pos < len(p.source) && strings.IndexByte(" \t\r\n", p.source[p.pos]) >= 0 {
+		p.pos++
 	}
-
-	cb.invalidExpr(expr)
-	return nil
+	p.tokPos = p.pos
+	if p.pos == len(p.source) {
+		p.tok = ""
+		return
+	}
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	isLetter := func(c byte) bool { return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+	switch c := p.source[p.pos]; {
+	case isDigit(c):
+		for p.pos < len(p.source) && isDigit(p.source[p.pos]) {
+			p.pos++
+		}
+	case isLetter(c):
+		for p.pos < len(p.source) && (isLetter(p.source[p.pos]) || isDigit(p.source[p.pos])) {
+			p.pos++
+		}
+	default:
+		p.pos++
+		if p.pos < len(p.source) {
+			if _, ok := pluralPrecedence[p.source[p.tokPos:p.pos+1]]; ok {
+				p.pos++
+			}
+		}
+	}
+	p.tok = p.source[p.tokPos:p.pos]
 }
 
-func (cb ruleBuilder) processComparsion(expr *ast.BinaryExpr) PluralRule {
-	switch expr.Op {
-	case token.LAND:
-		return and(cb.processExpression(expr.X), cb.processExpression(expr.Y))
-
-	case token.LOR:
-		return or(cb.processExpression(expr.X), cb.processExpression(expr.Y))
-
-	case token.EQL:
-		return eql(cb.processArithmetic(expr.X), cb.processArithmetic(expr.Y))
-
-	case token.NEQ:
-		return neq(cb.processArithmetic(expr.X), cb.processArithmetic(expr.Y))
-
-	case token.GTR:
-		return gtr(cb.processArithmetic(expr.X), cb.processArithmetic(expr.Y))
+// enter nested expression, leave should be deferred
+func (p *pluralParser) enter() {
+	p.depth++
+	if p.depth > maxPluralDepth {
+		p.fail("expression is nested deeper than %d", maxPluralDepth)
+	}
+}
 
-	case token.GEQ:
-		return geq(cb.processArithmetic(expr.X), cb.processArithmetic(expr.Y))
+func (p *pluralParser) leave() {
+	p.depth--
+}
 
-	case token.LSS:
-		return lss(cb.processArithmetic(expr.X), cb.processArithmetic(expr.Y))
+func (p *pluralParser) expect(tok string) {
+	if p.tok == "" {
+		p.fail("unexpected end of expression, expected '%s'", tok)
+	}
+	if p.tok != tok {
+		p.fail("expected '%s', found '%s'", tok, p.tok)
+	}
+	p.next()
+}
 
-	case token.LEQ:
-		return leq(cb.processArithmetic(expr.X), cb.processArithmetic(expr.Y))
+// parseTernary is right associative: a ? b : c ? d : e is a ? b : (c ? d : e)
+func (p *pluralParser) parseTernary() pluralExpr {
+	p.enter()
+	defer p.leave()
+	cond := p.parseBinary(1)
+	if p.tok != "?" {
+		return cond
 	}
+	p.next()
+	then := p.parseTernary()
+	p.expect(":")
+	other := p.parseTernary()
 
-	cb.invalidExpr(expr)
-	return nil
+	return pluralTernary{cond, then, other}
 }
 
-func (cb ruleBuilder) processArithmetic(expr ast.Expr) evaluer {
-	switch te := expr.(type) {
-	case *ast.BasicLit:
-		x, err := strconv.Atoi(te.Value)
-		if err == nil {
-			return number(x)
-		}
-
-	case *ast.Ident:
-		if te.Name == "n" {
-			return equiv()
+func (p *pluralParser) parseBinary(prec int) pluralExpr {
+	x := p.parseUnary()
+	for {
+		opPrec, ok := pluralPrecedence[p.tok]
+		if !ok || opPrec < prec {
+			return x
 		}
+		op := p.tok
+		p.next()
+		x = pluralBinary{op, x, p.parseBinary(opPrec + 1)}
+	}
+}
 
-	case *ast.BinaryExpr:
-		if te.Op == token.REM {
-			return mod(cb.processArithmetic(te.X), cb.processArithmetic(te.Y))
+func (p *pluralParser) parseUnary() pluralExpr {
+	p.enter()
+	defer p.leave()
+	switch tok := p.tok; {
+	case tok == "":
+		p.fail("unexpected end of expression")
+	case tok == "!":
+		p.next()
+		return pluralNot{p.parseUnary()}
+	case tok == "(":
+		p.next()
+		x := p.parseTernary()
+		p.expect(")")
+		return x
+	case tok == "n":
+		p.next()
+		return pluralVar{}
+	case tok[0] >= '0' && tok[0] <= '9':
+		x, err := strconv.ParseUint(tok, 10, 64)
+		if err != nil {
+			p.fail("invalid number '%s'", tok)
 		}
+		p.next()
+		return pluralConst(x)
+	case tok[0] == '_' || tok[0] >= 'a' && tok[0] <= 'z' || tok[0] >= 'A' && tok[0] <= 'Z':
+		p.fail("unknown identifier '%s'", tok)
 	}
+	p.fail("expected operand, found '%s'", p.tok)
 
-	cb.invalidExpr(expr)
-	return evaluer{}
-}
-
-func (cb ruleBuilder) invalidExpr(expr ast.Expr) {
-	panic(errors.Errorf(
-		"%d:%d: invalid expression '%s'",
-		expr.Pos(), expr.End(),
-		cb.source[int(expr.Pos())-1:int(expr.End())-1],
-	))
+	return nil
 }
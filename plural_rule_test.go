@@ -74,24 +74,47 @@ func TestParsePluralRule(t *testing.T) {
 			},
 		},
 		{
-			name:   "parsing error",
-			source: "n <> k",
-			err:    `1:4: expected operand, found '>'`,
+			name:   "arithmetic",
+			source: "n*2 - 1 > 10 && !(n/3 == 2)",
+			checks: map[int]bool{
+				5:  false,
+				6:  false,
+				7:  false,
+				9:  true,
+				-9: true,
+			},
 		},
 		{
-			name:   "invalid expression",
+			name:   "variable",
 			source: "n",
-			err:    `1:2: invalid expression 'n'`,
+			checks: map[int]bool{
+				0: false,
+				1: true,
+			},
+		},
+		{
+			name:   "unsigned",
+			source: "n - 2 > 100 || n % 0 != 0",
+			checks: map[int]bool{
+				1: true,
+				2: false,
+				3: false,
+			},
+		},
+		{
+			name:   "parsing error",
+			source: "n <> k",
+			err:    `1:4: expected operand, found '>'`,
 		},
 		{
-			name:   "unknown comparison",
-			source: "n + 2",
-			err:    `1:6: invalid expression 'n + 2'`,
+			name:   "unclosed parenthes",
+			source: "(n + 2",
+			err:    `1:7: unexpected end of expression, expected ')'`,
 		},
 		{
 			name:   "unknown symbol",
 			source: "n > k",
-			err:    `5:6: invalid expression 'k'`,
+			err:    `1:5: unknown identifier 'k'`,
 		},
 	}
 
@@ -201,6 +224,32 @@ func TestParsePluralRules(t *testing.T) {
 				116: 2,
 			},
 		},
+		{
+			name:   "Romanian nested",
+			source: "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+			checks: map[int]int{
+				0:   1,
+				1:   0,
+				19:  1,
+				20:  2,
+				101: 1,
+			},
+		},
+		{
+			name: "Arabic",
+			source: join(
+				`nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : `,
+				`n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;`,
+			),
+			checks: map[int]int{
+				0:   0,
+				1:   1,
+				2:   2,
+				5:   3,
+				11:  4,
+				100: 5,
+			},
+		},
 		{
 			name:   "invalid source format",
 			source: "something wrong",
@@ -214,38 +263,48 @@ func TestParsePluralRules(t *testing.T) {
 		{
 			name:   "one bad plural",
 			source: "nplurals=1; plural=1;",
-			err:    "unexpected choice 1, expected 0",
+			err:    "unexpected choice 1 for n = 0, expected less than 1",
 		},
 		{
-			name:   "two bad plurals",
+			name:   "constant plural",
 			source: "nplurals=2; plural=1;",
-			err:    "1:2: invalid expression '1'",
+			checks: map[int]int{
+				0: 1,
+				1: 1,
+			},
 		},
 		{
-			name:   "three bad plurals",
+			name:   "unknown identifier",
 			source: "nplurals=3; plural=n%10 == 1 && n%100 != 11 ? 0 : true ? 1 : 2;",
-			err:    "1:5: invalid expression 'true'",
+			err:    "1:32: unknown identifier 'true'",
 		},
 		{
-			name:   "bad number of rules",
-			source: "nplurals=3; plural=n%10 == 1 && n%100 != 11 ? 0 : 1;",
-			err:    "rules count missmatch",
+			name:   "missed alternative",
+			source: "nplurals=3; plural=n%10 == 1 && n%100 != 11 ? 0;",
+			err:    "1:29: unexpected end of expression, expected ':'",
 		},
 		{
-			name: "bad choice",
+			name: "choice out of range",
 			source: join(
 				`nplurals=3; plural=n%10 == 1 && n%100 != 11 ? 0 `,
-				`: n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 2 : 1;`,
+				`: n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 3;`,
 			),
-			err: "unexpected choice 2, expected 1",
+			err: "unexpected choice 3 for n = 0, expected less than 3",
 		},
 		{
-			name: "bad last choice",
-			source: join(
-				`nplurals=3; plural=n%10 == 1 && n%100 != 11 ? 0 `,
-				`: n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 3;`,
-			),
-			err: "unexpected choice 3, expected 2",
+			name:   "too long",
+			source: "nplurals=2; plural=" + strings.Repeat("(", 3<<20) + "n" + strings.Repeat(")", 3<<20) + ";",
+			err:    "source is longer than 1024 bytes",
+		},
+		{
+			name:   "too deep",
+			source: "nplurals=2; plural=" + strings.Repeat("(", 100) + "n" + strings.Repeat(")", 100) + ";",
+			err:    "1:33: expression is nested deeper than 64",
+		},
+		{
+			name:   "too many plurals",
+			source: "nplurals=1000000000; plural=n;",
+			err:    "nplurals shouldn't be greater than 64",
 		},
 	}
 
@@ -265,3 +324,76 @@ func TestParsePluralRules(t *testing.T) {
 		})
 	}
 }
+
+func TestPluralRulesForLanguage(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		lang   string
+		forms  int
+		checks map[int]int
+	}{
+		{"ru", 3, map[int]int{1: 0, 3: 1, 11: 2, 21: 0}},
+		{"ru-RU", 3, map[int]int{2: 1, 5: 2}},
+		{"pt", 2, map[int]int{0: 1, 1: 0}},
+		{"pt_BR", 2, map[int]int{0: 0, 1: 0, 2: 1}},
+		{"ja_JP.UTF-8", 1, map[int]int{1: 0, 2: 0}},
+		{"ar", 6, map[int]int{0: 0, 2: 2, 105: 3, 111: 4, 200: 5}},
+		{"he", 3, map[int]int{1: 0, 2: 1, 3: 2, 20: 2}},
+		{"lv", 3, map[int]int{0: 0, 1: 1, 2: 2, 11: 0, 21: 1}},
+		{"mk", 2, map[int]int{1: 0, 11: 1, 21: 0, 111: 1}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.lang, func(t *testing.T) {
+			rules, ok := pogo.PluralRulesForLanguage(c.lang)
+			require.True(t, ok)
+			assert.Equal(t, c.forms, rules.Len())
+			for n, r := range c.checks {
+				assert.Equal(t, r, rules.Eval(n), "n = %d", n)
+			}
+		})
+	}
+
+	_, ok := pogo.PluralRulesForLanguage("xx")
+	assert.False(t, ok)
+}
+
+func TestPluralCategory(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		lang       string
+		categories map[float64]string
+	}{
+		{"en", map[float64]string{0: "other", 1: "one", 2: "other", 1.5: "other"}},
+		{"fr", map[float64]string{0: "one", 1: "one", 2: "other"}},
+		{"ru", map[float64]string{1: "one", 2: "few", 5: "many", 11: "many", 21: "one", -22: "few"}},
+		{"uk", map[float64]string{1: "one", 3: "few", 12: "many"}},
+		{"hr", map[float64]string{1: "one", 2: "few", 5: "other", 11: "other", 21: "one"}},
+		{"sr", map[float64]string{1: "one", 4: "few", 10: "other", 111: "other"}},
+		{"bs", map[float64]string{1: "one", 22: "few", 25: "other"}},
+		{"pl", map[float64]string{1: "one", 2: "few", 5: "many", 21: "many", 22: "few"}},
+		{"cs", map[float64]string{1: "one", 3: "few", 5: "other"}},
+		{"lt", map[float64]string{1: "one", 2: "few", 10: "other", 11: "other", 21: "one"}},
+		{"lv", map[float64]string{0: "zero", 1: "one", 2: "other", 10: "zero", 11: "zero", 19: "zero", 21: "one"}},
+		{"mk", map[float64]string{1: "one", 2: "other", 11: "other", 21: "one", 111: "other"}},
+		{"ro", map[float64]string{0: "few", 1: "one", 19: "few", 20: "other", 101: "few"}},
+		{"sl", map[float64]string{1: "one", 2: "two", 3: "few", 5: "other", 101: "one"}},
+		{"is", map[float64]string{1: "one", 11: "other", 21: "one"}},
+		{"ga", map[float64]string{1: "one", 2: "two", 3: "few", 7: "many", 11: "other"}},
+		{"ar", map[float64]string{0: "zero", 1: "one", 2: "two", 3: "few", 11: "many", 100: "other"}},
+		{"ja", map[float64]string{1: "other", 2: "other"}},
+		{"xx", map[float64]string{1: "one", 2: "other"}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.lang, func(t *testing.T) {
+			for n, category := range c.categories {
+				assert.Equal(t, category, pogo.PluralCategory(c.lang, n), "n = %v", n)
+			}
+		})
+	}
+}
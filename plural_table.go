@@ -0,0 +1,186 @@
+package pogo
+
+import (
+	"strings"
+	"sync"
+)
+
+// pluralForms is a gettext Plural-Forms with CLDR category of each form
+//
+// CLDR plural category of integer is a category of form chosen by rules.
+type pluralForms struct {
+	source     string
+	categories []string
+
+	once  sync.Once
+	rules PluralRules
+}
+
+func newPluralForms(source string, categories ...string) *pluralForms {
+	return &pluralForms{source: source, categories: categories}
+}
+
+// Rules parsed once
+func (pf *pluralForms) Rules() PluralRules {
+	pf.once.Do(func() {
+		rules, err := ParsePluralRules(pf.source)
+		if err != nil {
+			panic(err)
+		}
+		if rules.Len() != len(pf.categories) {
+			panic("plural categories mismatch forms of " + pf.source)
+		}
+		pf.rules = rules
+	})
+
+	return pf.rules
+}
+
+// Category of integer n
+func (pf *pluralForms) Category(n int) string {
+	return pf.categories[pf.Rules().Eval(n)]
+}
+
+// Plural-Forms shared by many languages
+const (
+	pluralOnly     = "nplurals=1; plural=0;"
+	pluralOneOther = "nplurals=2; plural=n != 1;"
+	pluralZeroOne  = "nplurals=2; plural=n > 1;"
+	pluralEastSlav = "nplurals=3; plural=n%10==1 && n%100!=11 ? 0 : " +
+		"n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2;"
+	pluralWestSlav = "nplurals=3; plural=n==1 ? 0 : n>=2 && n<=4 ? 1 : 2;"
+	pluralPolish   = "nplurals=3; plural=n==1 ? 0 : " +
+		"n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2;"
+	pluralLithuanian = "nplurals=3; plural=n%10==1 && n%100!=11 ? 0 : " +
+		"n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2;"
+	pluralLatvian = "nplurals=3; plural=n%10==0 || (n%100>=11 && n%100<=19) ? 0 : " +
+		"n%10==1 && n%100!=11 ? 1 : 2;"
+	pluralRomanian = "nplurals=3; plural=n==1 ? 0 : " +
+		"(n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2;"
+	pluralSlovenian = "nplurals=4; plural=n%100==1 ? 0 : n%100==2 ? 1 : " +
+		"n%100==3 || n%100==4 ? 2 : 3;"
+	pluralHebrew = "nplurals=3; plural=n==1 ? 0 : n==2 ? 1 : 2;"
+	pluralWelsh  = "nplurals=4; plural=n==1 ? 0 : n==2 ? 1 : n != 8 && n != 11 ? 2 : 3;"
+	pluralIrish  = "nplurals=5; plural=n==1 ? 0 : n==2 ? 1 : n<7 ? 2 : n<11 ? 3 : 4;"
+	pluralArabic = "nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : " +
+		"n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;"
+)
+
+// pluralFormsTable is a table of Plural-Forms by language code as gettext
+// and CLDR define it
+//
+// Categories are listed per language, as languages sharing rules of forms
+// may name forms differently.
+var pluralFormsTable = map[string]*pluralForms{
+	"af":    newPluralForms(pluralOneOther, "one", "other"),
+	"ak":    newPluralForms(pluralZeroOne, "one", "other"),
+	"am":    newPluralForms(pluralZeroOne, "one", "other"),
+	"ar":    newPluralForms(pluralArabic, "zero", "one", "two", "few", "many", "other"),
+	"az":    newPluralForms(pluralOneOther, "one", "other"),
+	"be":    newPluralForms(pluralEastSlav, "one", "few", "many"),
+	"bg":    newPluralForms(pluralOneOther, "one", "other"),
+	"bn":    newPluralForms(pluralOneOther, "one", "other"),
+	"bs":    newPluralForms(pluralEastSlav, "one", "few", "other"),
+	"ca":    newPluralForms(pluralOneOther, "one", "other"),
+	"cs":    newPluralForms(pluralWestSlav, "one", "few", "other"),
+	"cy":    newPluralForms(pluralWelsh, "one", "two", "few", "other"),
+	"da":    newPluralForms(pluralOneOther, "one", "other"),
+	"de":    newPluralForms(pluralOneOther, "one", "other"),
+	"el":    newPluralForms(pluralOneOther, "one", "other"),
+	"en":    newPluralForms(pluralOneOther, "one", "other"),
+	"eo":    newPluralForms(pluralOneOther, "one", "other"),
+	"es":    newPluralForms(pluralOneOther, "one", "other"),
+	"et":    newPluralForms(pluralOneOther, "one", "other"),
+	"eu":    newPluralForms(pluralOneOther, "one", "other"),
+	"fa":    newPluralForms(pluralZeroOne, "one", "other"),
+	"fi":    newPluralForms(pluralOneOther, "one", "other"),
+	"fil":   newPluralForms(pluralZeroOne, "one", "other"),
+	"fo":    newPluralForms(pluralOneOther, "one", "other"),
+	"fr":    newPluralForms(pluralZeroOne, "one", "other"),
+	"fy":    newPluralForms(pluralOneOther, "one", "other"),
+	"ga":    newPluralForms(pluralIrish, "one", "two", "few", "many", "other"),
+	"gl":    newPluralForms(pluralOneOther, "one", "other"),
+	"gu":    newPluralForms(pluralOneOther, "one", "other"),
+	"he":    newPluralForms(pluralHebrew, "one", "two", "other"),
+	"hi":    newPluralForms(pluralZeroOne, "one", "other"),
+	"hr":    newPluralForms(pluralEastSlav, "one", "few", "other"),
+	"hu":    newPluralForms(pluralOneOther, "one", "other"),
+	"hy":    newPluralForms(pluralZeroOne, "one", "other"),
+	"id":    newPluralForms(pluralOnly, "other"),
+	"is":    newPluralForms("nplurals=2; plural=n%10 != 1 || n%100 == 11;", "one", "other"),
+	"it":    newPluralForms(pluralOneOther, "one", "other"),
+	"ja":    newPluralForms(pluralOnly, "other"),
+	"ka":    newPluralForms(pluralOneOther, "one", "other"),
+	"kk":    newPluralForms(pluralOneOther, "one", "other"),
+	"km":    newPluralForms(pluralOnly, "other"),
+	"ko":    newPluralForms(pluralOnly, "other"),
+	"ky":    newPluralForms(pluralOneOther, "one", "other"),
+	"lo":    newPluralForms(pluralOnly, "other"),
+	"lt":    newPluralForms(pluralLithuanian, "one", "few", "other"),
+	"lv":    newPluralForms(pluralLatvian, "zero", "one", "other"),
+	"mk":    newPluralForms("nplurals=2; plural=n%10==1 && n%100!=11 ? 0 : 1;", "one", "other"),
+	"ml":    newPluralForms(pluralOneOther, "one", "other"),
+	"mn":    newPluralForms(pluralOneOther, "one", "other"),
+	"mr":    newPluralForms(pluralOneOther, "one", "other"),
+	"ms":    newPluralForms(pluralOnly, "other"),
+	"my":    newPluralForms(pluralOnly, "other"),
+	"nb":    newPluralForms(pluralOneOther, "one", "other"),
+	"ne":    newPluralForms(pluralOneOther, "one", "other"),
+	"nl":    newPluralForms(pluralOneOther, "one", "other"),
+	"nn":    newPluralForms(pluralOneOther, "one", "other"),
+	"no":    newPluralForms(pluralOneOther, "one", "other"),
+	"oc":    newPluralForms(pluralZeroOne, "one", "other"),
+	"pa":    newPluralForms(pluralOneOther, "one", "other"),
+	"pl":    newPluralForms(pluralPolish, "one", "few", "many"),
+	"pt":    newPluralForms(pluralOneOther, "one", "other"),
+	"pt_br": newPluralForms(pluralZeroOne, "one", "other"),
+	"ro":    newPluralForms(pluralRomanian, "one", "few", "other"),
+	"ru":    newPluralForms(pluralEastSlav, "one", "few", "many"),
+	"sk":    newPluralForms(pluralWestSlav, "one", "few", "other"),
+	"sl":    newPluralForms(pluralSlovenian, "one", "two", "few", "other"),
+	"sq":    newPluralForms(pluralOneOther, "one", "other"),
+	"sr":    newPluralForms(pluralEastSlav, "one", "few", "other"),
+	"sv":    newPluralForms(pluralOneOther, "one", "other"),
+	"sw":    newPluralForms(pluralOneOther, "one", "other"),
+	"ta":    newPluralForms(pluralOneOther, "one", "other"),
+	"te":    newPluralForms(pluralOneOther, "one", "other"),
+	"th":    newPluralForms(pluralOnly, "other"),
+	"tl":    newPluralForms(pluralZeroOne, "one", "other"),
+	"tr":    newPluralForms(pluralOneOther, "one", "other"),
+	"uk":    newPluralForms(pluralEastSlav, "one", "few", "many"),
+	"ur":    newPluralForms(pluralOneOther, "one", "other"),
+	"uz":    newPluralForms(pluralOneOther, "one", "other"),
+	"vi":    newPluralForms(pluralOnly, "other"),
+	"zh":    newPluralForms(pluralOnly, "other"),
+}
+
+// lookupPluralForms of language
+//
+// Language may be a full locale name (pt_BR, pt-BR) or a language code. If
+// there is no forms for locale, forms of its language are used.
+func lookupPluralForms(lang string) (*pluralForms, bool) {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "-", "_"))
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	forms, ok := pluralFormsTable[lang]
+	if !ok {
+		forms, ok = pluralFormsTable[strings.SplitN(lang, "_", 2)[0]]
+	}
+
+	return forms, ok
+}
+
+// PluralRulesForLanguage returns well known plural rules of language
+//
+// Language may be a full locale name (pt_BR, pt-BR) or a language code. If
+// there is no rules for locale, rules of its language are used. Reports
+// false if language is unknown.
+func PluralRulesForLanguage(lang string) (PluralRules, bool) {
+	forms, ok := lookupPluralForms(lang)
+	if !ok {
+		return PluralRules{}, false
+	}
+
+	return forms.Rules(), true
+}
@@ -9,7 +9,12 @@ import (
 	"github.com/pkg/errors"
 )
 
-const prevBorder = "#| "
+const (
+	prevBorder = "#| "
+	// maxPluralIndex is a minimal limit of msgstr[N] index, the limit is
+	// raised to plural forms count if it is greater
+	maxPluralIndex = 16
+)
 
 // POEntry present one message
 type POEntry struct {
@@ -21,11 +26,51 @@ type POEntry struct {
 	PrevMsgID   string
 	PrevMsgIDP  string
 	MsgCtxt     string
-	MsgID       string
-	MsgIDP      string
-	MsgStr      string
-	MsgStrP     []string
-	Obsolete    bool
+	// HasMsgCtxt is set if entry has msgctxt, even empty one
+	HasMsgCtxt bool
+	MsgID      string
+	MsgIDP     string
+	MsgStr     string
+	MsgStrP    []string
+	Obsolete   bool
+	// Line of msgid in source, zero if entry is not read from source
+	Line int
+
+	source *entrySource
+	// missedForms are indexes of plural forms absent in source
+	missedForms []int
+}
+
+// entrySource is a source text of entry read in lossless mode
+//
+// Comment blocks and message blocks are kept apart to print not modified
+// part as is. If comments are mixed with messages, all blocks are kept in
+// messages.
+type entrySource struct {
+	lead     string // blank lines and skipped text before entry
+	comments string
+	messages string
+	mixed    bool
+	// entry as it was read
+	entry POEntry
+}
+
+func (src *entrySource) add(s *Scanner) {
+	src.lead += s.rawLead.String()
+	block := s.rawBlock.String()
+	switch {
+	case s.Border == "" || s.Border == "#~ ":
+		src.messages += block
+	case src.messages != "":
+		src.messages += block
+		src.mixed = true
+	default:
+		src.comments += block
+	}
+}
+
+func (src *entrySource) String() string {
+	return src.lead + src.comments + src.messages
 }
 
 var poStarters = []Starter{
@@ -62,13 +107,27 @@ var poStarters = []Starter{
 }
 
 // ReadPOEntry from scanner
+//
+// Plural forms are read as is, pluralCount only reserves capacity for them
+// and limits index of them. Use POFile.Validate to check forms count.
 func ReadPOEntry(s *Scanner, pluralCount int) (entry POEntry, err error) {
 	s.Starters = poStarters
+	var src *entrySource
+	if s.lossless {
+		src = &entrySource{}
+		defer func() {
+			src.entry = entry.snapshot()
+			entry.source = src
+		}()
+	}
 	for {
 		err = s.Scan()
 		if err != nil && errors.Cause(err) != io.EOF {
 			return
 		}
+		if src != nil {
+			src.add(s)
+		}
 		if applyErr := entry.applyBlock(s, pluralCount); applyErr != nil {
 			return entry, applyErr
 		}
@@ -114,12 +173,14 @@ func (entry *POEntry) mustApplyBlock(s *Scanner, pluralCount int) {
 		entry.PrevMsgIDP = s.Buffer.String()
 	case [2]string{"", "msgctxt "},
 		[2]string{"#~ ", "msgctxt "}:
-		entry.mustBeEmpty(s, entry.MsgCtxt)
+		entry.mustBeAbsent(s, entry.HasMsgCtxt)
 		entry.MsgCtxt = s.Buffer.String()
+		entry.HasMsgCtxt = true
 	case [2]string{"", "msgid "},
 		[2]string{"#~ ", "msgid "}:
 		entry.mustBeEmpty(s, entry.MsgID)
 		entry.MsgID = s.Buffer.String()
+		entry.Line = s.BlockLine
 	case [2]string{"", "msgid_plural "},
 		[2]string{"#~ ", "msgid_plural "}:
 		entry.mustBeEmpty(s, entry.MsgIDP)
@@ -138,44 +199,78 @@ func (entry *POEntry) checkObsolete(s *Scanner) {
 		if entry.Obsolete {
 			return
 		}
-		if entry.MsgCtxt != "" ||
+		if entry.HasMsgCtxt ||
 			entry.MsgID != "" ||
 			entry.MsgIDP != "" ||
 			entry.MsgStr != "" ||
 			entry.MsgStrP != nil {
-			panic(errors.Errorf("mixed obsolete and not obsolete blocks at %d", s.Line-1))
+			panic(s.blockError(KindMixedObsolete, 1, errors.New("mixed obsolete and not obsolete blocks")))
 		}
 		entry.Obsolete = true
 	}
 	if s.Border == "" {
 		if entry.Obsolete {
-			panic(errors.Errorf("mixed obsolete and not obsolete blocks at %d", s.Line-1))
+			panic(s.blockError(KindMixedObsolete, 1, errors.New("mixed obsolete and not obsolete blocks")))
 		}
 	}
 }
 
 func (entry *POEntry) updateMsgStrP(s *Scanner, pluralCount int) {
 	if !strings.HasPrefix(s.Prefix, "msgstr[") {
-		fmt.Println(s.Prefix, s.Border, s.Buffer.String())
+		// blank line
 		return
 	}
-	if entry.MsgStrP == nil {
-		entry.MsgStrP = make([]string, pluralCount)
+	limit := pluralCount
+	if limit < maxPluralIndex {
+		limit = maxPluralIndex
 	}
+	// column of index is 1-based
+	column := len(s.Border) + len("msgstr[") + 1
 	n, err := strconv.Atoi(s.Prefix[7 : len(s.Prefix)-2])
 	if err != nil {
-		panic(errors.WithStack(err))
+		panic(s.blockError(KindBadPluralIndex, column, errors.Wrap(err, "plural index")))
+	}
+	if n >= limit {
+		panic(s.blockError(KindBadPluralIndex, column,
+			errors.Errorf("plural index %d is out of limit %d", n, limit)))
 	}
-	if n >= pluralCount {
-		panic(errors.Errorf("unknown plural form %d at %d", n, s.Line-1))
+	if entry.MsgStrP == nil {
+		entry.MsgStrP = make([]string, 0, pluralCount)
+	}
+	for len(entry.MsgStrP) < n {
+		entry.missedForms = append(entry.missedForms, len(entry.MsgStrP))
+		entry.MsgStrP = append(entry.MsgStrP, "")
+	}
+	if len(entry.MsgStrP) == n {
+		entry.MsgStrP = append(entry.MsgStrP, "")
+	} else {
+		entry.mustBeAbsent(s, !entry.dropMissedForm(n))
 	}
-	entry.mustBeEmpty(s, entry.MsgStrP[n])
 	entry.MsgStrP[n] = s.Buffer.String()
 }
 
-func (POEntry) mustBeEmpty(s *Scanner, text string) {
-	if text != "" {
-		panic(errors.Errorf("duplicate block %q at %d", s.Border+s.Prefix, s.Line))
+// dropMissedForm returns false if form n is not missed
+func (entry *POEntry) dropMissedForm(n int) bool {
+	for i, k := range entry.missedForms {
+		if k == n {
+			entry.missedForms = append(entry.missedForms[:i], entry.missedForms[i+1:]...)
+			if len(entry.missedForms) == 0 {
+				entry.missedForms = nil
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+func (entry POEntry) mustBeEmpty(s *Scanner, text string) {
+	entry.mustBeAbsent(s, text != "")
+}
+
+func (POEntry) mustBeAbsent(s *Scanner, present bool) {
+	if present {
+		panic(s.blockError(KindDuplicate, 1, errors.Errorf("duplicate block %q", s.Border+s.Prefix)))
 	}
 }
 
@@ -186,77 +281,169 @@ func (entry *POEntry) Print(f *Formatter, width int) error {
 	})
 }
 
-//nolint:gocyclo // it ahould be at one place
 func (entry *POEntry) mustPrint(f *Formatter, width int) {
-	mustFormat := func(text string) {
-		if err := f.Format(text); err != nil {
-			panic(err)
-		}
+	src := entry.source
+	comments := src != nil && entry.sameComments(&src.entry)
+	messages := src != nil && entry.sameMessages(&src.entry)
+	if src != nil && src.mixed {
+		comments = comments && messages
+		messages = comments
 	}
 
+	if comments {
+		f.mustWriteRaw(src.comments)
+	} else {
+		entry.mustPrintComments(f)
+	}
+	if messages {
+		f.mustWriteRaw(src.messages)
+	} else {
+		entry.mustPrintMessages(f, width)
+	}
+}
+
+func (entry *POEntry) mustPrintComments(f *Formatter) {
 	f.Width = 0
 	if entry.TComment != "" {
 		f.Border, f.Prefix = "# ", ""
-		mustFormat(entry.TComment)
+		f.mustFormat(entry.TComment)
 	}
 	if entry.EComment != "" {
 		f.Border, f.Prefix = "#. ", ""
-		mustFormat(entry.EComment)
+		f.mustFormat(entry.EComment)
 	}
 	if entry.Reference != "" {
 		f.Border, f.Prefix = "#: ", ""
-		mustFormat(entry.Reference)
+		f.mustFormat(entry.Reference)
 	}
 	if len(entry.Flags) > 0 {
 		f.Border, f.Prefix = "#, ", ""
-		mustFormat(entry.Flags.String())
+		f.mustFormat(entry.Flags.String())
 	}
 	if entry.PrevMsgCtxt != "" {
 		f.Border, f.Prefix = prevBorder, "msgctxt "
-		mustFormat(entry.PrevMsgCtxt)
+		f.mustFormat(entry.PrevMsgCtxt)
 	}
 	if entry.PrevMsgID != "" {
 		f.Border, f.Prefix = prevBorder, "msgid "
-		mustFormat(entry.PrevMsgID)
+		f.mustFormat(entry.PrevMsgID)
 	}
 	if entry.PrevMsgIDP != "" {
 		f.Border, f.Prefix = prevBorder, "msgid_plural "
-		mustFormat(entry.PrevMsgIDP)
+		f.mustFormat(entry.PrevMsgIDP)
 	}
+}
 
+func (entry *POEntry) mustPrintMessages(f *Formatter, width int) {
 	f.Border = ""
 	f.Width = width
 	if entry.Obsolete {
 		f.Border = "#~ "
 	}
-	if entry.MsgCtxt != "" {
+	if entry.hasContext() {
 		f.Prefix = "msgctxt "
-		mustFormat(entry.MsgCtxt)
+		f.mustFormat(entry.MsgCtxt)
 	}
 	f.Prefix = "msgid "
-	mustFormat(entry.MsgID)
+	f.mustFormat(entry.MsgID)
 	if entry.MsgIDP != "" {
 		f.Prefix = "msgid_plural "
-		mustFormat(entry.MsgIDP)
+		f.mustFormat(entry.MsgIDP)
 	}
 	if len(entry.MsgStrP) == 0 {
 		f.Prefix = "msgstr "
-		mustFormat(entry.MsgStr)
+		f.mustFormat(entry.MsgStr)
 	}
 	for i := range entry.MsgStrP {
 		f.Prefix = fmt.Sprintf("msgstr[%d] ", i)
-		mustFormat(entry.MsgStrP[i])
+		f.mustFormat(entry.MsgStrP[i])
+	}
+}
+
+// snapshot of entry fields to find modifications
+func (entry *POEntry) snapshot() POEntry {
+	res := *entry
+	res.source = nil
+	if entry.Flags != nil {
+		res.Flags = append(Flags{}, entry.Flags...)
+	}
+	if entry.MsgStrP != nil {
+		res.MsgStrP = append([]string{}, entry.MsgStrP...)
+	}
+
+	return res
+}
+
+// sameComments reports are comments of entries equal
+func (entry *POEntry) sameComments(other *POEntry) bool {
+	return entry.TComment == other.TComment &&
+		entry.EComment == other.EComment &&
+		entry.Reference == other.Reference &&
+		equalStrings(entry.Flags, other.Flags) &&
+		entry.PrevMsgCtxt == other.PrevMsgCtxt &&
+		entry.PrevMsgID == other.PrevMsgID &&
+		entry.PrevMsgIDP == other.PrevMsgIDP
+}
+
+// sameMessages reports are messages of entries equal
+func (entry *POEntry) sameMessages(other *POEntry) bool {
+	return entry.Obsolete == other.Obsolete &&
+		entry.HasMsgCtxt == other.HasMsgCtxt &&
+		entry.MsgCtxt == other.MsgCtxt &&
+		entry.MsgID == other.MsgID &&
+		entry.MsgIDP == other.MsgIDP &&
+		entry.MsgStr == other.MsgStr &&
+		equalStrings(entry.MsgStrP, other.MsgStrP)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
 	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isTranslated returns false if any of translations is empty or missed
+func (entry *POEntry) isTranslated(pluralCount int) bool {
+	if len(entry.MsgStrP) == 0 {
+		return entry.MsgStr != ""
+	}
+	if len(entry.MsgStrP) < pluralCount {
+		return false
+	}
+	for i := range entry.MsgStrP {
+		if entry.MsgStrP[i] == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasContext reports is entry has msgctxt
+//
+// Entries built without HasMsgCtxt are treated as having context if MsgCtxt
+// is not empty.
+func (entry *POEntry) hasContext() bool {
+	return entry.HasMsgCtxt || entry.MsgCtxt != ""
 }
 
 // Update return merge result of entry with next version
 func (entry *POEntry) Update(next *POEntry) POEntry {
 	res := *entry
 	res.EComment = next.EComment
-	if res.MsgCtxt != next.MsgCtxt {
+	res.Obsolete = next.Obsolete
+	if res.MsgCtxt != next.MsgCtxt || res.hasContext() != next.hasContext() {
 		res.PrevMsgCtxt, res.MsgCtxt = res.MsgCtxt, next.MsgCtxt
 		res.Flags.Add("fuzzy")
 	}
+	res.HasMsgCtxt = next.HasMsgCtxt
 	if res.MsgID != next.MsgID {
 		res.PrevMsgID, res.MsgID = res.MsgID, next.MsgID
 		res.Flags.Add("fuzzy")
@@ -74,6 +74,26 @@ func TestReadPOEntry(t *testing.T) {
 			plural: 2,
 			err:    "",
 		},
+		{
+			name: "empty context",
+			source: join(
+				`msgctxt ""`,
+				`msgid "MsgID"`,
+				`msgstr "MsgStr"`,
+			),
+			plural: 2,
+			err:    "",
+		},
+		{
+			name: "duplicate empty context",
+			source: join(
+				`msgctxt ""`,
+				`msgctxt ""`,
+				`msgid "MsgID"`,
+			),
+			plural: 2,
+			err:    `2:1: duplicate block "msgctxt "`,
+		},
 	}
 
 	for _, c := range cases {
@@ -3,7 +3,6 @@ package pogo
 import (
 	"io"
 
-	"github.com/pkg/errors"
 	"github.com/vporoshok/muzzy"
 )
 
@@ -14,34 +13,100 @@ const DefaultWidth = 80
 type POFile struct {
 	Header
 	Entries []POEntry
+
+	// trailer is a source text after the last entry in lossless mode
+	trailer string
 }
 
 // ReadPOFile from reader
+//
+// With WithRecovery option malformed entries are skipped, and file is
+// returned together with ParseErrors. With WithLossless option not modified
+// file is printed byte-for-byte as it was read.
 func ReadPOFile(r io.Reader, opts ...ScannerOption) (*POFile, error) {
-	po := &POFile{}
+	dec := NewDecoder(r, opts...)
+	var errs ParseErrors
+	collect := func(err error) error {
+		if perr, ok := err.(*ParseError); ok && dec.s.Recovery && perr.Kind != KindIO {
+			errs = append(errs, perr)
+			return nil
+		}
+		return err
+	}
 
-	s := NewScanner(r, opts...)
-	first := true
+	po := &POFile{}
+	header, err := dec.Header()
+	if err = collect(err); err != nil {
+		return nil, err
+	}
+	po.Header = header
 	for {
-		entry, err := ReadPOEntry(s, po.PluralForms.Len())
-		if err != nil && errors.Cause(err) != io.EOF {
-			return nil, err
-		}
-		if entry.MsgID != "" {
+		entry, err := dec.Next()
+		switch {
+		case err == io.EOF:
+			po.trailer = dec.skipped
+			return po, errs.errorOrNil()
+		case err != nil:
+			if err = collect(err); err != nil {
+				return nil, err
+			}
+		default:
 			po.Entries = append(po.Entries, entry)
-		} else if first {
-			po.Header.FromEntry(&entry)
-		}
-		first = false
-		if err != nil {
-			return po, nil
 		}
 	}
 }
 
+// DefaultSimilarity is a minimal similarity of messages to fuzzy match
+const DefaultSimilarity = 0.8
+
+type updateConfig struct {
+	similarity    float64
+	fuzzyMatching bool
+	keepObsolete  bool
+}
+
+// UpdateOption customize merge of po files
+type UpdateOption func(*updateConfig)
+
+// WithSimilarity set minimal similarity of messages to fuzzy match
+func WithSimilarity(threshold float64) UpdateOption {
+	return func(cfg *updateConfig) {
+		cfg.similarity = threshold
+	}
+}
+
+// WithFuzzyMatching enable or disable fuzzy matching (enabled by default)
+func WithFuzzyMatching(enabled bool) UpdateOption {
+	return func(cfg *updateConfig) {
+		cfg.fuzzyMatching = enabled
+	}
+}
+
+// WithObsolete keep or drop entries missed in next version (kept by default)
+func WithObsolete(keep bool) UpdateOption {
+	return func(cfg *updateConfig) {
+		cfg.keepObsolete = keep
+	}
+}
+
 // Update file with next version
-func (po *POFile) Update(next *POFile) *POFile {
+//
+// Entries of next version are matched with entries of file exactly by
+// context and message. If there is no exact match and fuzzy matching is
+// enabled, the most similar message is used and result entry is marked as
+// fuzzy. Not matched entries of file are marked as obsolete.
+func (po *POFile) Update(next *POFile, opts ...UpdateOption) *POFile {
+	cfg := updateConfig{
+		similarity:    DefaultSimilarity,
+		fuzzyMatching: true,
+		keepObsolete:  true,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	recycle := make([]bool, len(po.Entries))
+	exact := make(map[string]int, len(po.Entries))
 	index := muzzy.NewSplitIndex(muzzy.NGramSplitter(3, true))
 	entryID := func(entry POEntry) string {
 		if entry.MsgIDP != "" {
@@ -49,26 +114,39 @@ func (po *POFile) Update(next *POFile) *POFile {
 		}
 		return entry.MsgID
 	}
+	entryKey := func(entry POEntry) string {
+		return messageID(entry.hasContext(), entry.MsgCtxt, entry.MsgID)
+	}
 
 	for i := range po.Entries {
+		if _, ok := exact[entryKey(po.Entries[i])]; !ok {
+			exact[entryKey(po.Entries[i])] = i
+		}
 		index.Add(entryID(po.Entries[i]))
 	}
 
 	res := make([]POEntry, len(next.Entries))
 	for i := range next.Entries {
-		j := index.Search(entryID(next.Entries[i]))
-		if j >= 0 {
-			d := index.Similarity(entryID(po.Entries[j]), entryID(next.Entries[i]))
-			if d > 0.8 {
-				res[i] = po.Entries[j].Update(&next.Entries[i])
-				recycle[j] = true
-				continue
+		if j, ok := exact[entryKey(next.Entries[i])]; ok {
+			res[i] = po.Entries[j].Update(&next.Entries[i])
+			recycle[j] = true
+			continue
+		}
+		if cfg.fuzzyMatching {
+			j := index.Search(entryID(next.Entries[i]))
+			if j >= 0 {
+				d := index.Similarity(entryID(po.Entries[j]), entryID(next.Entries[i]))
+				if d > cfg.similarity {
+					res[i] = po.Entries[j].Update(&next.Entries[i])
+					recycle[j] = true
+					continue
+				}
 			}
 		}
 		res[i] = next.Entries[i]
 	}
 	for i, ok := range recycle {
-		if !ok {
+		if !ok && cfg.keepObsolete {
 			entry := po.Entries[i]
 			if !entry.Obsolete {
 				entry.Obsolete = true
@@ -77,6 +155,11 @@ func (po *POFile) Update(next *POFile) *POFile {
 		}
 	}
 
+	// merged entries have no source lines
+	for i := range res {
+		res[i].Line = 0
+	}
+
 	return &POFile{
 		Header:  next.Header,
 		Entries: res,
@@ -84,35 +167,69 @@ func (po *POFile) Update(next *POFile) *POFile {
 }
 
 // Print po-file to writer
-func (po *POFile) Print(w io.Writer) error {
-	f := NewFormatter(w)
-	header := po.Header.ToEntry()
-	if err := header.Print(f, DefaultWidth); err != nil {
+func (po *POFile) Print(w io.Writer, opts ...PrintOption) error {
+	enc := NewEncoder(w, opts...)
+	if err := enc.EncodeHeader(&po.Header); err != nil {
 		return err
 	}
 	for i := range po.Entries {
-		if err := f.BreakLine(); err != nil {
-			return err
-		}
-		if err := po.Entries[i].Print(f, DefaultWidth); err != nil {
+		if err := enc.Encode(&po.Entries[i]); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return enc.writeRaw(po.trailer)
+}
+
+type moConfig struct {
+	fuzzy        bool
+	obsolete     bool
+	untranslated bool
+}
+
+// MOOption customize conversion of po file to mo file
+//
+// By default fuzzy, obsolete and untranslated entries are skipped as msgfmt
+// do it.
+type MOOption func(*moConfig)
+
+// WithFuzzyEntries include entries marked as fuzzy
+func WithFuzzyEntries() MOOption {
+	return func(cfg *moConfig) {
+		cfg.fuzzy = true
+	}
+}
+
+// WithObsoleteEntries include obsolete entries
+func WithObsoleteEntries() MOOption {
+	return func(cfg *moConfig) {
+		cfg.obsolete = true
+	}
+}
+
+// WithUntranslatedEntries include entries with empty translations
+func WithUntranslatedEntries() MOOption {
+	return func(cfg *moConfig) {
+		cfg.untranslated = true
+	}
 }
 
 // MO convert to mo-file
-func (po *POFile) MO() *MOFile {
+func (po *POFile) MO(opts ...MOOption) *MOFile {
+	var cfg moConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	mo := &MOFile{
 		Header:  po.Header,
 		Entries: make(map[string][]string, len(po.Entries)),
 	}
 	for i := range po.Entries {
-		id := po.Entries[i].MsgID
-		if po.Entries[i].MsgCtxt != "" {
-			id = po.Entries[i].MsgCtxt + ctxtSep + id
+		if !cfg.accept(&po.Entries[i], po.PluralForms.Len()) {
+			continue
 		}
+		id := messageID(po.Entries[i].hasContext(), po.Entries[i].MsgCtxt, po.Entries[i].MsgID)
 		val := []string{po.Entries[i].MsgStr}
 		if po.Entries[i].MsgStrP != nil {
 			id += pluralSep + po.Entries[i].MsgIDP
@@ -124,3 +241,9 @@ func (po *POFile) MO() *MOFile {
 
 	return mo
 }
+
+func (cfg moConfig) accept(entry *POEntry, pluralCount int) bool {
+	return (cfg.obsolete || !entry.Obsolete) &&
+		(cfg.fuzzy || !entry.Flags.Contain("fuzzy")) &&
+		(cfg.untranslated || entry.isTranslated(pluralCount))
+}
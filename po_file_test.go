@@ -2,9 +2,14 @@ package pogo_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gotest.tools/golden"
@@ -132,6 +137,81 @@ func TestFileMerge(t *testing.T) {
 	}
 }
 
+func TestFileMergeOptions(t *testing.T) {
+	t.Parallel()
+
+	join := func(lines ...string) string { return strings.Join(lines, "\n") }
+
+	curr := join(
+		`msgid ""`, `msgstr ""`, `"Language: ru_RU\n"`, ``,
+		`msgid "Some text with misstype fro example"`, `msgstr "Один"`, ``,
+		`#~ msgid "Two"`, `#~ msgstr "Два"`, ``,
+	)
+	next := join(
+		`msgid ""`, `msgstr ""`, `"Language: ru_RU\n"`, ``,
+		`msgid "Some text without misstype for example"`, `msgstr ""`, ``,
+		`msgid "Two"`, `msgstr ""`, ``,
+	)
+
+	cases := [...]struct {
+		name   string
+		opts   []pogo.UpdateOption
+		result []pogo.POEntry
+	}{
+		{
+			"default",
+			nil,
+			[]pogo.POEntry{
+				{
+					Flags:     pogo.Flags{"fuzzy"},
+					PrevMsgID: "Some text with misstype fro example",
+					MsgID:     "Some text without misstype for example",
+					MsgStr:    "Один",
+				},
+				{MsgID: "Two", MsgStr: "Два"},
+			},
+		},
+		{
+			"high similarity",
+			[]pogo.UpdateOption{pogo.WithSimilarity(0.99)},
+			[]pogo.POEntry{
+				{MsgID: "Some text without misstype for example"},
+				{MsgID: "Two", MsgStr: "Два"},
+				{MsgID: "Some text with misstype fro example", MsgStr: "Один", Obsolete: true},
+			},
+		},
+		{
+			"no fuzzy matching",
+			[]pogo.UpdateOption{pogo.WithFuzzyMatching(false)},
+			[]pogo.POEntry{
+				{MsgID: "Some text without misstype for example"},
+				{MsgID: "Two", MsgStr: "Два"},
+				{MsgID: "Some text with misstype fro example", MsgStr: "Один", Obsolete: true},
+			},
+		},
+		{
+			"no obsolete",
+			[]pogo.UpdateOption{pogo.WithFuzzyMatching(false), pogo.WithObsolete(false)},
+			[]pogo.POEntry{
+				{MsgID: "Some text without misstype for example"},
+				{MsgID: "Two", MsgStr: "Два"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			curr, err := pogo.ReadPOFile(bytes.NewBufferString(curr))
+			require.NoError(t, err)
+			next, err := pogo.ReadPOFile(bytes.NewBufferString(next))
+			require.NoError(t, err)
+			result := curr.Update(next, c.opts...)
+			assert.Equal(t, c.result, result.Entries)
+		})
+	}
+}
+
 func TestPOtoMO(t *testing.T) {
 	data := golden.Get(t, "example.po")
 	buf := bytes.NewBuffer(data)
@@ -142,3 +222,249 @@ func TestPOtoMO(t *testing.T) {
 	require.NoError(t, mo.Write(res))
 	golden.AssertBytes(t, res.Bytes(), "example_output.mo")
 }
+
+func TestPOtoMOHashTable(t *testing.T) {
+	// example.mo is compiled by msgfmt from example.po
+	po, err := pogo.ReadPOFile(bytes.NewBuffer(golden.Get(t, "example.po")))
+	require.NoError(t, err)
+	res := new(bytes.Buffer)
+	require.NoError(t, po.MO().Write(res))
+
+	expected := golden.Get(t, "example.mo")
+	size := binary.LittleEndian.Uint32(expected[20:])
+	offset := binary.LittleEndian.Uint32(expected[24:])
+	require.Equal(t, expected[20:28], res.Bytes()[20:28])
+	assert.Equal(t, expected[offset:offset+size*4], res.Bytes()[offset:offset+size*4])
+}
+
+func TestPOtoMOHashTableSize(t *testing.T) {
+	t.Parallel()
+
+	// sizes follow write-mo.c of gettext, header is counted as a message
+	cases := [...]struct {
+		messages int
+		size     uint32
+	}{
+		{0, 3},
+		{1, 5},
+		{2, 5},
+		{3, 5},
+		{4, 7},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(strconv.Itoa(c.messages), func(t *testing.T) {
+			lines := []string{`msgid ""`, `msgstr "Content-Type: text/plain; charset=UTF-8\n"`, ``}
+			for i := 0; i < c.messages; i++ {
+				lines = append(lines, fmt.Sprintf(`msgid "id %d"`, i), fmt.Sprintf(`msgstr "str %d"`, i), ``)
+			}
+			po, err := pogo.ReadPOFile(bytes.NewBufferString(strings.Join(lines, "\n")))
+			require.NoError(t, err)
+			res := new(bytes.Buffer)
+			require.NoError(t, po.MO().Write(res))
+			assert.Equal(t, uint32(c.messages+1), binary.LittleEndian.Uint32(res.Bytes()[8:]))
+			assert.Equal(t, c.size, binary.LittleEndian.Uint32(res.Bytes()[20:]))
+		})
+	}
+}
+
+func TestPOtoMOOptions(t *testing.T) {
+	t.Parallel()
+
+	po, err := pogo.ReadPOFile(bytes.NewBufferString(strings.Join([]string{
+		`msgid ""`, `msgstr ""`, `"Plural-Forms: nplurals=2; plural=n != 1;\n"`, ``,
+		`msgid "One"`, `msgstr "Один"`, ``,
+		`#, fuzzy`, `msgid "Two"`, `msgstr "Два"`, ``,
+		`msgid "Three"`, `msgstr ""`, ``,
+		`msgid "%d file"`, `msgid_plural "%d files"`, `msgstr[0] "%d файл"`, ``,
+		`#~ msgid "Four"`, `#~ msgstr "Четыре"`, ``,
+	}, "\n")))
+	require.NoError(t, err)
+
+	cases := [...]struct {
+		name string
+		opts []pogo.MOOption
+		keys []string
+	}{
+		{"default", nil, []string{"One"}},
+		{"fuzzy", []pogo.MOOption{pogo.WithFuzzyEntries()}, []string{"One", "Two"}},
+		{"obsolete", []pogo.MOOption{pogo.WithObsoleteEntries()}, []string{"Four", "One"}},
+		{
+			"untranslated",
+			[]pogo.MOOption{pogo.WithUntranslatedEntries()},
+			[]string{"%d file\x00%d files", "One", "Three"},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			mo := po.MO(c.opts...)
+			keys := make([]string, 0, len(mo.Entries))
+			for key := range mo.Entries {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			assert.Equal(t, c.keys, keys)
+		})
+	}
+}
+
+func TestPOFileEmptyContext(t *testing.T) {
+	t.Parallel()
+
+	source := strings.Join([]string{
+		`msgid "Open"`, `msgstr "Открыть"`, ``,
+		`msgctxt ""`, `msgid "Open"`, `msgstr "Открыть файл"`, ``,
+	}, "\n")
+	po, err := pogo.ReadPOFile(bytes.NewBufferString(source))
+	require.NoError(t, err)
+	require.Len(t, po.Entries, 2)
+	assert.False(t, po.Entries[0].HasMsgCtxt)
+	assert.True(t, po.Entries[1].HasMsgCtxt)
+
+	merged := po.Update(po)
+	require.Len(t, merged.Entries, 2)
+	assert.Equal(t, "Открыть", merged.Entries[0].MsgStr)
+	assert.Equal(t, "Открыть файл", merged.Entries[1].MsgStr)
+	assert.True(t, merged.Entries[1].HasMsgCtxt)
+	assert.Empty(t, merged.Entries[1].Flags)
+
+	mo := po.MO()
+	assert.Equal(t, "Открыть", mo.Get("Open"))
+	assert.Equal(t, "Открыть файл", mo.GetCtxt("Open", ""))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, po.Print(buf))
+	assert.Contains(t, buf.String(), "msgctxt \"\"\nmsgid \"Open\"")
+}
+
+func TestPOFileLossless(t *testing.T) {
+	t.Parallel()
+
+	data := golden.Get(t, "lossless.po")
+	po, err := pogo.ReadPOFile(bytes.NewReader(data), pogo.WithLossless())
+	require.NoError(t, err)
+	assert.Equal(t, "ru", po.Language)
+	assert.True(t, po.Fuzzy)
+	assert.Equal(t, pogo.Flags{"c-format", "no-wrap"}, po.Entries[0].Flags)
+	assert.Equal(t, "a.go:1\nb.go:2", po.Entries[0].Reference)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, po.Print(buf))
+	assert.Equal(t, string(data), buf.String())
+
+	crlf := "msgid \"One\"\r\nmsgstr \"Один\"\r\n\r\nmsgid \"Two\"\r\nmsgstr \"Два\""
+	other, err := pogo.ReadPOFile(strings.NewReader(crlf), pogo.WithLossless())
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, other.Print(buf))
+	assert.Equal(t, crlf, buf.String())
+
+	po.Language = "uk"
+	po.Entries[0].MsgStr = "Привет %s, это короткая строка"
+	po.Entries[2].TComment = "Obsolete"
+	po.Entries = append(po.Entries, pogo.POEntry{MsgID: "Three", MsgStr: "Три"})
+	buf.Reset()
+	require.NoError(t, po.Print(buf, pogo.WithPrintWidth(0)))
+	golden.Assert(t, buf.String(), "lossless_edited.po")
+}
+
+func TestReadPOFileErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name   string
+		lines  []string
+		err    string
+		kind   pogo.ParseErrorKind
+		column int
+		text   string
+	}{
+		{
+			"duplicate",
+			[]string{`msgid "One"`, `msgstr "Один"`, `msgstr "Раз"`},
+			`ru.po:3:1: duplicate block "msgstr "`,
+			pogo.KindDuplicate,
+			1,
+			`msgstr "Раз"`,
+		},
+		{
+			"mixed obsolete",
+			[]string{`msgid "One"`, `msgstr "Один"`, ``, `msgid "Two"`, `#~ msgstr "Два"`},
+			`ru.po:5:1: mixed obsolete and not obsolete blocks`,
+			pogo.KindMixedObsolete,
+			1,
+			`#~ msgstr "Два"`,
+		},
+		{
+			"huge plural index",
+			[]string{`msgid "%d file"`, `msgid_plural "%d files"`, `msgstr[300000000] "x"`},
+			`ru.po:3:8: plural index 300000000 is out of limit 16`,
+			pogo.KindBadPluralIndex,
+			8,
+			`msgstr[300000000] "x"`,
+		},
+		{
+			"obsolete huge plural index",
+			[]string{`#~ msgid "%d file"`, `#~ msgid_plural "%d files"`, `#~ msgstr[300000000] "x"`},
+			`ru.po:3:11: plural index 300000000 is out of limit 16`,
+			pogo.KindBadPluralIndex,
+			11,
+			`#~ msgstr[300000000] "x"`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := pogo.ReadPOFile(bytes.NewBufferString(strings.Join(c.lines, "\n")), pogo.WithFilename("ru.po"))
+			require.Error(t, err)
+			assert.EqualError(t, err, c.err)
+			perr, ok := errors.Cause(err).(*pogo.ParseError)
+			require.True(t, ok)
+			assert.Equal(t, c.kind, perr.Kind)
+			assert.Equal(t, c.column, perr.Column)
+			assert.Equal(t, c.text, perr.Text)
+		})
+	}
+}
+
+func TestReadPOFileRecovery(t *testing.T) {
+	t.Parallel()
+
+	source := strings.Join([]string{
+		`msgid ""`, `msgstr ""`, `"Language: ru\n"`, ``,
+		`msgid "One"`, `msgstr "Один"`, `msgstr "Раз"`, ``,
+		`msgid "Two"`, `msgstr "Два"`, ``,
+		`msgid "Three"`, `wrong "line"`, `msgstr "Три"`, ``,
+		`msgid "Four"`, `msgstr "Четыре`, ``,
+		`msgid "Five"`, `msgstr "Пять"`,
+	}, "\n")
+
+	_, err := pogo.ReadPOFile(bytes.NewBufferString(source))
+	require.Error(t, err)
+
+	po, err := pogo.ReadPOFile(bytes.NewBufferString(source), pogo.WithRecovery(), pogo.WithFilename("ru.po"))
+	require.Error(t, err)
+	assert.Equal(t, "ru", po.Language)
+	ids := make([]string, len(po.Entries))
+	for i := range po.Entries {
+		ids[i] = po.Entries[i].MsgID
+	}
+	assert.Equal(t, []string{"Two", "Five"}, ids)
+
+	errs, ok := err.(pogo.ParseErrors)
+	require.True(t, ok, err)
+	require.Len(t, errs, 3)
+	assert.Equal(t, []pogo.ParseErrorKind{pogo.KindDuplicate, pogo.KindNoStarter, pogo.KindBadQuotes},
+		[]pogo.ParseErrorKind{errs[0].Kind, errs[1].Kind, errs[2].Kind})
+	assert.Equal(t, []int{7, 13, 17}, []int{errs[0].Line, errs[1].Line, errs[2].Line})
+	assert.Equal(t, strings.Join([]string{
+		`3 parse errors:`,
+		`ru.po:7:1: duplicate block "msgstr "`,
+		`ru.po:13:1: no starter is matched`,
+		`ru.po:17:8: unquote: invalid syntax`,
+	}, "\n"), err.Error())
+}
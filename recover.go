@@ -9,9 +9,12 @@ func recoverHandledError(fn func()) (err error) {
 				error
 				StackTrace() errors.StackTrace
 			}
-			if e, ok := rec.(withStacktrace); ok {
+			switch e := rec.(type) {
+			case *ParseError:
 				err = e
-			} else {
+			case withStacktrace:
+				err = e
+			default:
 				panic(rec)
 			}
 		}
@@ -10,6 +10,9 @@ import (
 	"github.com/pkg/errors"
 )
 
+// DefaultMaxLineSize is a maximum length of line in source
+const DefaultMaxLineSize = 64 << 20
+
 // Scanner to extract blocks by permitted starters
 type Scanner struct {
 	// Starters is a set of permitted starters
@@ -22,8 +25,26 @@ type Scanner struct {
 	Buffer *bytes.Buffer
 	// Line is current line number
 	Line int
+	// BlockLine is a number of the first line of the last read block
+	BlockLine int
+	// Filename is used in parse errors
+	Filename string
+	// Recovery makes ReadPOFile skip malformed entries and collect errors
+	Recovery bool
 
-	input *bufio.Scanner
+	input     *bufio.Scanner
+	text      string
+	blockText string
+	// source text of the last read block and blank lines before it, it is
+	// kept only in lossless mode
+	lossless bool
+	rawText  string
+	rawLead  strings.Builder
+	rawBlock strings.Builder
+	// charset of source, nil is UTF-8
+	charset    *charset
+	charsetSet bool
+	charsetErr error
 }
 
 // ScannerOption customize scanner
@@ -36,12 +57,56 @@ func WithBuffer(buf []byte, max int) ScannerOption {
 	}
 }
 
+// WithFilename set name of source to be reported in parse errors
+func WithFilename(name string) ScannerOption {
+	return func(s *Scanner) {
+		s.Filename = name
+	}
+}
+
+// WithCharset set charset of source
+//
+// By default Decoder and ReadPOFile use charset declared in header, Scanner
+// itself expects UTF-8.
+func WithCharset(name string) ScannerOption {
+	return func(s *Scanner) {
+		var ok bool
+		s.charsetSet = true
+		if s.charset, ok = lookupCharset(name); !ok {
+			s.charsetErr = errors.Errorf("unknown charset %q", name)
+		}
+	}
+}
+
+// WithRecovery makes ReadPOFile skip malformed entries instead of failing
+//
+// Parsing is resumed from the next blank line after an error. Result file
+// contains all well formed entries and errors are returned as ParseErrors.
+func WithRecovery() ScannerOption {
+	return func(s *Scanner) {
+		s.Recovery = true
+	}
+}
+
+// WithLossless keep source text of entries
+//
+// Entries and header read in this mode are printed back as is, only
+// modified parts of them are formatted again. So printing of not modified
+// file reproduces it byte-for-byte.
+func WithLossless() ScannerOption {
+	return func(s *Scanner) {
+		s.lossless = true
+	}
+}
+
 // NewScanner to read from r
 func NewScanner(r io.Reader, opts ...ScannerOption) *Scanner {
 	s := &Scanner{
 		Buffer: &bytes.Buffer{},
 		input:  bufio.NewScanner(r),
 	}
+	s.input.Buffer(nil, DefaultMaxLineSize)
+	s.input.Split(scanRawLines)
 	for _, opt := range opts {
 		opt(s)
 	}
@@ -50,7 +115,22 @@ func NewScanner(r io.Reader, opts ...ScannerOption) *Scanner {
 
 // IsBlankLine return true if current line is blank
 func (s *Scanner) IsBlankLine() bool {
-	return s.input.Text() == ""
+	return s.text == ""
+}
+
+// SkipToBlankLine skip lines of malformed entry up to the next blank line
+func (s *Scanner) SkipToBlankLine() error {
+	for s.text != "" {
+		if !s.scanInput() {
+			if err := s.input.Err(); err != nil {
+				return s.parseError(KindIO, s.Line, 1, "", errors.WithStack(err))
+			}
+			return errors.WithStack(io.EOF)
+		}
+		s.Line++
+	}
+
+	return nil
 }
 
 // Scan next block
@@ -59,56 +139,102 @@ func (s *Scanner) Scan() error {
 }
 
 func (s *Scanner) mustScan() {
+	if s.charsetErr != nil {
+		panic(s.charsetErr)
+	}
 	s.Border, s.Prefix = "", ""
 	s.Buffer.Reset()
+	s.rawLead.Reset()
+	s.rawBlock.Reset()
 	s.skipBlankLines()
+	s.BlockLine = s.Line
+	s.blockText = s.text
 	s.start()
 	s.mustReadLine(len(s.Border) + len(s.Prefix))
 	border := s.Border
 	if s.Prefix != "" {
 		border += `"`
 	}
-	for s.input.Scan() {
+	for s.scanInput() {
 		s.Line++
-		if s.input.Text() == "" || !strings.HasPrefix(s.input.Text(), border) {
+		if s.text == "" || !strings.HasPrefix(s.text, border) {
 			return
 		}
+		// comment line of another kind could start with the same border
+		if s.Prefix == "" {
+			if b, p, _ := s.match(s.text); b != s.Border || p != "" {
+				return
+			}
+		}
 		s.mustReadLine(len(s.Border))
 	}
-	err := s.input.Err()
-	if err == nil {
-		err = io.EOF
+	if err := s.input.Err(); err != nil {
+		panic(s.parseError(KindIO, s.Line, 1, "", errors.WithStack(err)))
 	}
-	panic(errors.WithStack(err))
+	panic(errors.WithStack(io.EOF))
+}
+
+// scanInput read next line and decode it to UTF-8
+func (s *Scanner) scanInput() bool {
+	ok := s.input.Scan()
+	raw := s.charset.decode(s.input.Text())
+	s.text = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+	if s.lossless {
+		s.rawText = raw
+	}
+
+	return ok
+}
+
+// scanRawLines is a bufio.ScanLines keeping line breaks in tokens
+func scanRawLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
 }
 
 func (s *Scanner) skipBlankLines() {
-	for s.input.Text() == "" {
+	for s.text == "" {
+		s.rawLead.WriteString(s.rawText)
 		s.Line++
-		if !s.input.Scan() {
+		if !s.scanInput() {
 			if s.input.Err() == nil {
 				panic(errors.WithStack(io.EOF))
 			}
-			panic(errors.WithStack(s.input.Err()))
+			panic(s.parseError(KindIO, s.Line, 1, "", errors.WithStack(s.input.Err())))
 		}
 	}
 }
 
 func (s *Scanner) start() {
+	s.Border, s.Prefix, _ = s.match(s.text)
+	if s.Border == "" && s.Prefix == "" {
+		panic(s.parseError(KindNoStarter, s.Line, 1, s.text, errors.New("no starter is matched")))
+	}
+}
+
+// match line by the first suitable starter
+func (s *Scanner) match(line string) (border, prefix string, ok bool) {
 	for _, starter := range s.Starters {
-		if border, prefix, ok := starter.Extract(s.input.Text()); ok {
-			s.Border = border
-			s.Prefix = prefix
-			break
+		if border, prefix, ok = starter.Extract(line); ok {
+			return
 		}
 	}
-	if s.Border == "" && s.Prefix == "" {
-		panic(errors.Errorf("no starter is matched line %d", s.Line))
-	}
+
+	return "", "", false
 }
 
 func (s *Scanner) mustReadLine(skip int) {
-	line := s.input.Text()[skip:]
+	s.rawBlock.WriteString(s.rawText)
+	line := s.text[skip:]
 	if s.Prefix == "" {
 		if s.Buffer.Len() > 0 {
 			s.mustWrite("\n")
@@ -118,11 +244,28 @@ func (s *Scanner) mustReadLine(skip int) {
 	}
 	unquoted, err := strconv.Unquote(strings.TrimSpace(line))
 	if err != nil {
-		panic(errors.WithStack(err))
+		column := skip + len(line) - len(strings.TrimLeft(line, " \t")) + 1
+		panic(s.parseError(KindBadQuotes, s.Line, column, s.text, errors.Wrap(err, "unquote")))
 	}
 	s.mustWrite(s.unescape(unquoted))
 }
 
+func (s *Scanner) parseError(kind ParseErrorKind, line, column int, text string, err error) *ParseError {
+	return &ParseError{
+		Filename: s.Filename,
+		Line:     line,
+		Column:   column,
+		Text:     text,
+		Kind:     kind,
+		Err:      err,
+	}
+}
+
+// blockError is a parse error at the first line of the last read block
+func (s *Scanner) blockError(kind ParseErrorKind, column int, err error) *ParseError {
+	return s.parseError(kind, s.BlockLine, column, s.blockText, err)
+}
+
 func (s *Scanner) mustWrite(text string) {
 	if _, err := s.Buffer.WriteString(text); err != nil {
 		panic(errors.WithStack(err))
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vporoshok/pogo"
 )
@@ -120,13 +121,24 @@ func TestScannerError(t *testing.T) {
 		name   string
 		source string
 		err    string
+		kind   pogo.ParseErrorKind
 	}{
 		{
 			name: "no starter found",
 			source: join(
 				`#) Some comment here`,
 			),
-			err: "no starter is matched line 1",
+			err:  "1:1: no starter is matched",
+			kind: pogo.KindNoStarter,
+		},
+		{
+			name: "bad quotes",
+			source: join(
+				`msgid ""`,
+				`"unclosed`,
+			),
+			err:  "2:1: unquote: invalid syntax",
+			kind: pogo.KindBadQuotes,
 		},
 	}
 
@@ -143,7 +155,29 @@ func TestScannerError(t *testing.T) {
 			source := bytes.NewBufferString(c.source)
 			s := pogo.NewScanner(source)
 			s.Starters = starters
-			assert.EqualError(t, s.Scan(), c.err)
+			err := s.Scan()
+			assert.EqualError(t, err, c.err)
+			perr, ok := errors.Cause(err).(*pogo.ParseError)
+			require.True(t, ok)
+			assert.Equal(t, c.kind, perr.Kind)
 		})
 	}
 }
+
+func TestScannerErrorText(t *testing.T) {
+	t.Parallel()
+
+	s := pogo.NewScanner(bytes.NewBufferString("# comment\n\n#) wrong\n"), pogo.WithFilename("ru.po"))
+	s.Starters = []pogo.Starter{pogo.NewPlainStarter("# ", "")}
+	require.NoError(t, s.Scan())
+	err := s.Scan()
+	assert.EqualError(t, err, "ru.po:3:1: no starter is matched")
+	assert.Equal(t, &pogo.ParseError{
+		Filename: "ru.po",
+		Line:     3,
+		Column:   1,
+		Text:     "#) wrong",
+		Kind:     pogo.KindNoStarter,
+		Err:      errors.Cause(err).(*pogo.ParseError).Err,
+	}, err)
+}
@@ -0,0 +1,266 @@
+package pogo
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// charsetSniffSize is a size of file beginning to find charset of header
+//
+// Charset is needed before header is parsed, so Content-Type of header
+// should be in the beginning of file (as it is in files of gettext tools).
+// Declaration beyond the limit is reported by Decoder.Header if it differs
+// from UTF-8.
+const charsetSniffSize = 4096
+
+// Decoder reads po file entry by entry
+//
+// Decoder keeps in memory only current entry, so it could be used to
+// process huge files. With WithRecovery option malformed entries are
+// reported by Next as ParseError and decoding continues from the next one.
+type Decoder struct {
+	s       *Scanner
+	header  Header
+	started bool
+	pending *POEntry
+	eof     bool
+	err     error
+	// skipped is a source text of skipped entries in lossless mode, it is
+	// attached to the next entry
+	skipped string
+}
+
+// NewDecoder to read from r
+//
+// Source is decoded to UTF-8 by charset declared in header (see also
+// WithCharset option). Charset is searched in the first 4096 bytes of
+// source, unknown charset is reported as error on read.
+func NewDecoder(r io.Reader, opts ...ScannerOption) *Decoder {
+	br := bufio.NewReaderSize(r, charsetSniffSize)
+	s := NewScanner(br, opts...)
+	if !s.charsetSet {
+		data, _ := br.Peek(charsetSniffSize)
+		s.charset, s.charsetErr = sniffPOCharset(data)
+	}
+
+	return &Decoder{s: s}
+}
+
+// Header of file
+//
+// Header is read from the first entry if its msgid is empty, otherwise
+// default header is returned.
+func (dec *Decoder) Header() (Header, error) {
+	if dec.started {
+		return dec.header, dec.err
+	}
+	dec.started = true
+	entry, err := dec.readEntry()
+	if err != nil {
+		return dec.header, err
+	}
+	if entry.MsgID == "" {
+		dec.header.FromEntry(&entry)
+		if err := dec.checkCharset(); err != nil {
+			dec.err = err
+			return dec.header, err
+		}
+	} else {
+		dec.pending = &entry
+		if dec.s.lossless {
+			dec.header.source = newHeaderSource(&dec.header, nil)
+		}
+	}
+
+	return dec.header, nil
+}
+
+// checkCharset of header to be the charset source is decoded by
+func (dec *Decoder) checkCharset() error {
+	if dec.s.charsetSet {
+		return nil
+	}
+	cs, err := charsetOfContentType(dec.header.ContentType)
+	if err != nil {
+		return err
+	}
+	if cs.String() != dec.s.charset.String() {
+		return errors.Errorf("charset %s of header is not declared in the first %d bytes of file",
+			cs, charsetSniffSize)
+	}
+
+	return nil
+}
+
+// Next entry of file, io.EOF is returned at the end of file
+func (dec *Decoder) Next() (POEntry, error) {
+	if !dec.started {
+		if _, err := dec.Header(); err != nil {
+			return POEntry{}, err
+		}
+	}
+	if dec.pending != nil {
+		entry := *dec.pending
+		dec.pending = nil
+		return entry, nil
+	}
+	for {
+		if dec.err != nil {
+			return POEntry{}, dec.err
+		}
+		if dec.eof {
+			return POEntry{}, io.EOF
+		}
+		entry, err := dec.readEntry()
+		if err != nil {
+			return POEntry{}, err
+		}
+		if entry.MsgID != "" {
+			dec.attachSkipped(&entry)
+			return entry, nil
+		}
+		if entry.source != nil {
+			dec.skipped += entry.source.String()
+		}
+	}
+}
+
+// attachSkipped source text to the lead of entry
+func (dec *Decoder) attachSkipped(entry *POEntry) {
+	if dec.skipped != "" && entry.source != nil {
+		entry.source.lead = dec.skipped + entry.source.lead
+		dec.skipped = ""
+	}
+}
+
+func (dec *Decoder) readEntry() (POEntry, error) {
+	entry, err := ReadPOEntry(dec.s, dec.header.PluralForms.Len())
+	switch {
+	case err == nil:
+		return entry, nil
+	case errors.Cause(err) == io.EOF:
+		dec.eof = true
+		return entry, nil
+	}
+	perr, ok := errors.Cause(err).(*ParseError)
+	if !dec.s.Recovery || !ok || perr.Kind == KindIO {
+		dec.err = err
+		return POEntry{}, err
+	}
+	if err := dec.s.SkipToBlankLine(); err != nil {
+		if errors.Cause(err) == io.EOF {
+			dec.eof = true
+		} else {
+			dec.err = err
+		}
+	}
+
+	return POEntry{}, perr
+}
+
+// PrintOption customize printing of po file
+type PrintOption func(*Encoder)
+
+// WithPrintCharset encode output to charset and declare it in header
+//
+// By default charset declared in header is used.
+func WithPrintCharset(name string) PrintOption {
+	return func(enc *Encoder) {
+		enc.charset = name
+	}
+}
+
+// WithPrintWidth set width of msgctxt, msgid and msgstr lines
+//
+// By default DefaultWidth is used, lines are not wrapped if width is less or
+// equal zero.
+func WithPrintWidth(width int) PrintOption {
+	return func(enc *Encoder) {
+		enc.Width = width
+	}
+}
+
+// Encoder writes po file entry by entry
+//
+// Entries read in lossless mode (see WithLossless option) are printed as
+// they were read, only modified parts of them are formatted.
+type Encoder struct {
+	// Width of msgctxt, msgid and msgstr lines
+	Width int
+
+	f       *Formatter
+	w       *charsetWriter
+	charset string
+	written bool
+}
+
+// NewEncoder to write to w
+//
+// Output is encoded by charset declared in header passed to EncodeHeader
+// (see also WithPrintCharset option).
+func NewEncoder(w io.Writer, opts ...PrintOption) *Encoder {
+	cw := &charsetWriter{w: w}
+	enc := &Encoder{
+		Width: DefaultWidth,
+		f:     NewFormatter(cw),
+		w:     cw,
+	}
+	for _, opt := range opts {
+		opt(enc)
+	}
+
+	return enc
+}
+
+// EncodeHeader as the first entry
+func (enc *Encoder) EncodeHeader(header *Header) error {
+	if enc.charset != "" {
+		cs, ok := lookupCharset(enc.charset)
+		if !ok {
+			return errors.Errorf("unknown charset %q", enc.charset)
+		}
+		enc.w.cs = cs
+		copied := *header
+		copied.ContentType = withCharset(header.ContentType, enc.charset)
+		header = &copied
+	} else {
+		cs, err := charsetOfContentType(header.ContentType)
+		if err != nil {
+			return err
+		}
+		enc.w.cs = cs
+	}
+	entry, ok := header.printEntry()
+	if !ok {
+		return nil
+	}
+
+	return enc.Encode(&entry)
+}
+
+// Encode entry, entries are separated by blank line
+//
+// Entry read in lossless mode is separated by blank lines it was read with.
+func (enc *Encoder) Encode(entry *POEntry) error {
+	switch {
+	case entry.source != nil && entry.source.lead != "":
+		if err := enc.writeRaw(entry.source.lead); err != nil {
+			return err
+		}
+	case enc.written:
+		if err := enc.f.BreakLine(); err != nil {
+			return err
+		}
+	}
+	enc.written = true
+
+	return entry.Print(enc.f, enc.Width)
+}
+
+func (enc *Encoder) writeRaw(text string) error {
+	return recoverHandledError(func() {
+		enc.f.mustWriteRaw(text)
+	})
+}
@@ -0,0 +1,91 @@
+package pogo_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/golden"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestDecoderEncoder(t *testing.T) {
+	data := golden.Get(t, "example.po")
+	po, err := pogo.ReadPOFile(bytes.NewReader(data))
+	require.NoError(t, err)
+	expected := &bytes.Buffer{}
+	require.NoError(t, po.Print(expected))
+
+	dec := pogo.NewDecoder(bytes.NewReader(data))
+	header, err := dec.Header()
+	require.NoError(t, err)
+	assert.Equal(t, po.Header, header)
+
+	res := &bytes.Buffer{}
+	enc := pogo.NewEncoder(res)
+	require.NoError(t, enc.EncodeHeader(&header))
+	n := 0
+	for {
+		entry, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, enc.Encode(&entry))
+		n++
+	}
+	assert.Equal(t, len(po.Entries), n)
+	assert.Equal(t, expected.String(), res.String())
+}
+
+func TestDecoderWithoutHeader(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 100<<10)
+	dec := pogo.NewDecoder(strings.NewReader(strings.Join([]string{
+		`msgid "One"`, `msgstr "Один"`, ``,
+		`msgid "Long"`, `msgstr "` + long + `"`,
+	}, "\n")))
+
+	entry, err := dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "One", entry.MsgID)
+	header, err := dec.Header()
+	require.NoError(t, err)
+	assert.Equal(t, "", header.Language)
+	entry, err = dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, long, entry.MsgStr)
+	_, err = dec.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderRecovery(t *testing.T) {
+	t.Parallel()
+
+	source := strings.Join([]string{
+		`msgid "One"`, `msgstr "Один"`, `msgstr "Раз"`, ``,
+		`msgid "Two"`, `msgstr "Два"`,
+	}, "\n")
+
+	dec := pogo.NewDecoder(strings.NewReader(source))
+	_, err := dec.Next()
+	require.Error(t, err)
+	_, err = dec.Next()
+	require.Error(t, err)
+
+	dec = pogo.NewDecoder(strings.NewReader(source), pogo.WithRecovery())
+	_, err = dec.Next()
+	perr, ok := err.(*pogo.ParseError)
+	require.True(t, ok, err)
+	assert.Equal(t, 3, perr.Line)
+	entry, err := dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "Два", entry.MsgStr)
+	_, err = dec.Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -6,6 +6,8 @@ type wait chan struct{}
 
 type syncLoader struct {
 	m sync.Map
+	// mu serializes replacements of values
+	mu sync.Mutex
 }
 
 func (sl *syncLoader) Load(key interface{}, fn func() interface{}) interface{} {
@@ -19,7 +21,8 @@ func (sl *syncLoader) Load(key interface{}, fn func() interface{}) interface{} {
 		return sl.await(key, value)
 	}
 	value := fn()
-	sl.m.Store(key, value)
+	// value stored while loading is fresher than loaded one
+	sl.Replace(key, ch, value)
 	close(ch)
 
 	return value
@@ -32,3 +35,37 @@ func (sl *syncLoader) await(key, value interface{}) interface{} {
 	}
 	return value
 }
+
+// Store value without waiting of running load
+//
+// Running load doesn't overwrite stored value.
+func (sl *syncLoader) Store(key, value interface{}) {
+	sl.mu.Lock()
+	sl.m.Store(key, value)
+	sl.mu.Unlock()
+}
+
+// Replace value only if it is still old one, reports is it replaced
+func (sl *syncLoader) Replace(key, old, value interface{}) bool {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if cur, ok := sl.m.Load(key); !ok || cur != old {
+		return false
+	}
+	sl.m.Store(key, value)
+
+	return true
+}
+
+// Keys of all stored values, loading ones are skipped
+func (sl *syncLoader) Keys() []interface{} {
+	var keys []interface{}
+	sl.m.Range(func(key, value interface{}) bool {
+		if _, ok := value.(wait); !ok {
+			keys = append(keys, key)
+		}
+		return true
+	})
+
+	return keys
+}
@@ -35,3 +35,23 @@ func TestSyncLoader(t *testing.T) {
 	assert.EqualValues(t, 1, v)
 	assert.EqualValues(t, 1, counter)
 }
+
+func TestSyncLoaderStoreWhileLoading(t *testing.T) {
+	sl := new(syncLoader)
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan interface{})
+	go func() {
+		done <- sl.Load(1, func() interface{} {
+			close(started)
+			<-release
+			return "stale"
+		})
+	}()
+	<-started
+	assert.Empty(t, sl.Keys())
+	sl.Store(1, "fresh")
+	assert.Equal(t, []interface{}{1}, sl.Keys())
+	close(release)
+	<-done
+	assert.Equal(t, "fresh", sl.Load(1, func() interface{} { return "again" }))
+}
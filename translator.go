@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 )
 
 type langCtxKey struct{}
@@ -27,11 +29,17 @@ func ContextWithLanguage(ctx context.Context, lang string) context.Context {
 }
 
 // Locale is an abstract to get translate by parameters
+//
+// If translation is missed or empty, original message should be returned.
+// Lookup returns same translation as other methods (plural form is used if
+// plural is not empty, context is used if ctxt is not nil) and report is
+// translation found.
 type Locale interface {
 	Get(msg string) string
 	GetN(msg, plural string, n int) string
 	GetCtxt(msg, ctxt string) string
 	GetCtxtN(msg, plural, ctxt string, n int) string
+	Lookup(msg, plural string, ctxt *string, n int) (string, bool)
 }
 
 // Loader is a locale factory
@@ -39,72 +47,6 @@ type Loader interface {
 	Load(lang, domain string) (Locale, error)
 }
 
-type fileLoader struct {
-	pattern string
-}
-
-func (fl fileLoader) Load(lang, domain string) (Locale, error) {
-	loc, err := fl.load(lang, domain)
-	if err != nil {
-		langs := strings.SplitN(lang, "_", 2)
-		if len(langs) == 2 {
-			return fl.load(langs[0], domain)
-		}
-	}
-	return loc, err
-}
-func (fl fileLoader) load(lang, domain string) (Locale, error) {
-	if moFile, err := fl.getFile(lang, domain, "mo"); err == nil {
-		defer func() {
-			_ = moFile.Close()
-		}()
-		return ReadMOFile(moFile)
-	}
-	poFile, err := fl.getFile(lang, domain, "po")
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = poFile.Close()
-	}()
-	po, err := ReadPOFile(poFile)
-	if err != nil {
-		return nil, err
-	}
-	return po.MO(), nil
-}
-
-func (fl fileLoader) getFile(lang, domain, ext string) (io.ReadCloser, error) {
-	filepath := fl.pattern
-	filepath = strings.Replace(filepath, "{{ language }}", lang, -1) //nolint:gocritic // backward compatibility
-	filepath = strings.Replace(filepath, "{{ domain }}", domain, -1) //nolint:gocritic // backward compatibility
-	filepath = strings.Replace(filepath, "{{ ext }}", ext, -1)       //nolint:gocritic // backward compatibility
-
-	return os.Open(filepath) //nolint:gosec // it is just library
-}
-
-// FileLoader standard loader po and mo files from disk
-//
-// Pattern should contain next three parts:
-// - `{{ language }}` – language in form 'ru_RU' or 'ru';
-// - `{{ domain }}` – resources domain (default is 'default');
-// - `{{ ext }}` – file extension (mo | po).
-//
-// For example "./data/locales/{{ language }}/{{ domain }}.{{ ext }}".
-//
-// File will be loaded on first request with given language and domain. First
-// loader try to get mo file with full language name. If any error occurred,
-// try to get po file with full language name. On error loader try to get mo
-// and po files with short language name. So for default domain and language
-// ru_RU sequence of tries in example patter will be next:
-// 1. "./data/locales/ru_RU/default.mo";
-// 2. "./data/locales/ru_RU/default.po";
-// 3. "./data/locales/ru/default.mo";
-// 4. "./data/locales/ru/default.po";
-func FileLoader(pattern string) Loader {
-	return fileLoader{pattern}
-}
-
 // Logger is an interface to log errors
 type Logger interface {
 	Printf(string, ...interface{})
@@ -112,10 +54,24 @@ type Logger interface {
 
 // Translator is an object to translate strings
 type Translator struct {
-	lang    string
-	loader  Loader
-	logger  Logger
-	locales syncLoader
+	lang       string
+	sourceLang string
+	loader     Loader
+	logger     Logger
+	fallbacks  map[string][]string
+	locales    syncLoader
+	modTimes   sync.Map
+	retryMin   time.Duration
+	retryMax   time.Duration
+
+	watchCtx      context.Context
+	watchInterval time.Duration
+	// now is a clock of retries, it is replaced in tests
+	now func() time.Time
+	// ticker of watcher, it is replaced in tests
+	ticker func(time.Duration) (<-chan time.Time, func())
+
+	messageFormats mfCache
 }
 
 // TranslatorOption is an additional configuration to translator object
@@ -136,26 +92,83 @@ func WithLogger(logger Logger) TranslatorOption {
 	})
 }
 
+// WithFallback set chain of languages to search messages missed in lang
+//
+// For example, WithFallback("pt_BR", "pt", "es", "en") means that message
+// missed in "pt_BR" catalog will be searched in "pt", "es" and "en" catalogs
+// with the same domain and context. If chain for full language name (pt_BR)
+// is not set, chain for short name (pt) is used. Chain for empty lang is
+// applied to all languages after their own chains.
+func WithFallback(lang string, fallbacks ...string) TranslatorOption {
+	return fnTranslatorOption(func(t *Translator) {
+		if t.fallbacks == nil {
+			t.fallbacks = make(map[string][]string)
+		}
+		t.fallbacks[lang] = fallbacks
+	})
+}
+
+// DefaultSourceLanguage is a language of original messages
+const DefaultSourceLanguage = "en"
+
+// WithSourceLanguage set language of original messages (DefaultSourceLanguage
+// by default)
+//
+// It is used to format messages missed in all catalogs.
+func WithSourceLanguage(lang string) TranslatorOption {
+	return fnTranslatorOption(func(t *Translator) {
+		t.sourceLang = lang
+	})
+}
+
+// Default delays between attempts to load failed catalog
+const (
+	DefaultRetryMin = time.Second
+	DefaultRetryMax = 5 * time.Minute
+)
+
+// WithRetry set delays between attempts to load failed catalog
+//
+// Delay starts from min and doubles on each failed attempt up to max. Not
+// positive min disables retries, so failed catalog is not loaded until
+// reload. Repeated errors are logged not often than max delay.
+func WithRetry(min, max time.Duration) TranslatorOption {
+	return fnTranslatorOption(func(t *Translator) {
+		t.retryMin = min
+		t.retryMax = max
+	})
+}
+
 // NewTranslator with given language and loader
 func NewTranslator(lang string, loader Loader, opts ...TranslatorOption) *Translator {
 	t := &Translator{
-		lang:   lang,
-		loader: loader,
-		logger: log.New(os.Stderr, "pogo", log.LstdFlags),
+		lang:       lang,
+		sourceLang: DefaultSourceLanguage,
+		loader:     loader,
+		logger:     log.New(os.Stderr, "pogo", log.LstdFlags),
+
+		retryMin: DefaultRetryMin,
+		retryMax: DefaultRetryMax,
+		now:      time.Now,
+		ticker:   newTicker,
 	}
 	for _, opt := range opts {
 		opt.apply(t)
 	}
+	if t.watchInterval > 0 {
+		go t.watch()
+	}
 
 	return t
 }
 
 type translateConfig struct {
-	domain    string
-	ctxt      string
-	pluralN   int
-	pluralID  string
-	formatter func(string) (string, error)
+	domain   string
+	ctxt     *string
+	pluralN  int
+	pluralID string
+	// formatter gets language of catalog where message is found
+	formatter func(t *Translator, lang, msg string) (string, error)
 }
 
 func makeDefaultTranslateConfig() translateConfig {
@@ -185,9 +198,11 @@ func WithDomain(domain string) TranslateOption {
 }
 
 // WithContext add context to search msg
+//
+// Empty context differs from no context, as in pgettext.
 func WithContext(ctxt string) TranslateOption {
 	return fnTranslateOption(func(cfg translateConfig) translateConfig {
-		cfg.ctxt = ctxt
+		cfg.ctxt = &ctxt
 		return cfg
 	})
 }
@@ -204,7 +219,7 @@ func WithPlural(pluralForm string, n int) TranslateOption {
 // WithGoFormat format message as fmt.Sprintf
 func WithGoFormat(args ...interface{}) TranslateOption {
 	return fnTranslateOption(func(cfg translateConfig) translateConfig {
-		cfg.formatter = func(msg string) (string, error) {
+		cfg.formatter = func(_ *Translator, _, msg string) (string, error) {
 			return fmt.Sprintf(msg, args...), nil
 		}
 		return cfg
@@ -214,7 +229,7 @@ func WithGoFormat(args ...interface{}) TranslateOption {
 // WithGoTemplate format message as text/template
 func WithGoTemplate(data interface{}) TranslateOption {
 	return fnTranslateOption(func(cfg translateConfig) translateConfig {
-		cfg.formatter = func(msg string) (string, error) {
+		cfg.formatter = func(_ *Translator, _, msg string) (string, error) {
 			tmpl, err := template.New("").Parse(msg)
 			if err != nil {
 				return "", err
@@ -233,7 +248,9 @@ func WithGoTemplate(data interface{}) TranslateOption {
 //
 // Try extract language to translate from context or use default language.
 // If there is no locale for given language and domain pair, or if msg is not
-// found, return message as is.
+// found, return message as is (or plural form by rule n == 1). Message is
+// formatted by rules of language of catalog where it is found, or of source
+// language if it is missed.
 func (t *Translator) Translate(ctx context.Context, msg string, opts ...TranslateOption) string {
 	cfg := makeDefaultTranslateConfig()
 	for _, opt := range opts {
@@ -243,11 +260,11 @@ func (t *Translator) Translate(ctx context.Context, msg string, opts ...Translat
 	if !ok {
 		lang = t.lang
 	}
-	str := t.getMessage(lang, msg, cfg)
+	str, lang, _ := t.getMessage(lang, msg, cfg)
 	if cfg.formatter == nil {
 		return str
 	}
-	res, err := cfg.formatter(str)
+	res, err := cfg.formatter(t, lang, str)
 	if err != nil {
 		t.logger.Printf("error on format message %q: %+v", str, err)
 		return str
@@ -256,32 +273,136 @@ func (t *Translator) Translate(ctx context.Context, msg string, opts ...Translat
 	return res
 }
 
-func (t *Translator) getMessage(lang, msg string, cfg translateConfig) string {
-	loc := t.getLocale(lang, cfg.domain)
-	if loc == nil {
-		return msg
+// getMessage returns translation and language of catalog where it is found
+func (t *Translator) getMessage(lang, msg string, cfg translateConfig) (string, string, bool) {
+	plural := ""
+	if cfg.pluralN >= 0 {
+		plural = cfg.pluralID
 	}
-	if cfg.ctxt == "" {
-		if cfg.pluralN < 0 {
-			return loc.Get(msg)
+	for _, lang := range t.languageChain(lang) {
+		loc := t.getLocale(lang, cfg.domain)
+		if loc == nil {
+			continue
+		}
+		if str, ok := loc.Lookup(msg, plural, cfg.ctxt, cfg.pluralN); ok {
+			return str, lang, true
 		}
-		return loc.GetN(msg, cfg.pluralID, cfg.pluralN)
 	}
-	if cfg.pluralN < 0 {
-		return loc.GetCtxt(msg, cfg.ctxt)
+	if plural != "" {
+		return sourcePlural(msg, plural, cfg.pluralN), t.sourceLang, false
+	}
+
+	return msg, t.sourceLang, false
+}
+
+func (t *Translator) languageChain(lang string) []string {
+	chain := []string{lang}
+	if len(t.fallbacks) == 0 {
+		return chain
+	}
+	fallbacks, ok := t.fallbacks[lang]
+	if !ok {
+		fallbacks = t.fallbacks[strings.SplitN(lang, "_", 2)[0]]
+	}
+	chain = append(chain, fallbacks...)
+	chain = append(chain, t.fallbacks[""]...)
+
+	res := chain[:0]
+	exists := make(map[string]bool, len(chain))
+	for _, lang := range chain {
+		if !exists[lang] {
+			res = append(res, lang)
+			exists[lang] = true
+		}
 	}
-	return loc.GetCtxtN(msg, cfg.pluralID, cfg.ctxt, cfg.pluralN)
+
+	return res
+}
+
+type localeKey struct {
+	lang, domain string
+}
+
+func (key localeKey) String() string {
+	return path.Join(key.lang, key.domain)
 }
 
 func (t *Translator) getLocale(lang, domain string) Locale {
-	loc, _ := t.locales.Load(path.Join(lang, domain), func() interface{} {
-		loc, err := t.loader.Load(lang, domain)
-		if err != nil {
-			t.logger.Printf("error on load locale %q: %+v", path.Join(lang, domain), err)
+	key := localeKey{lang, domain}
+	for {
+		switch value := t.locales.Load(key, func() interface{} {
+			return t.tryLoadLocale(key, nil)
+		}).(type) {
+		case Locale:
+			return value
+		case *loadFailure:
+			if t.retryMin <= 0 || t.now().Before(value.retryAt) ||
+				!atomic.CompareAndSwapInt32(&value.retrying, 0, 1) {
+				return nil
+			}
+			res := t.tryLoadLocale(key, value)
+			// catalog reloaded while retrying is kept, so it is loaded again
+			if t.locales.Replace(key, value, res) {
+				loc, _ := res.(Locale)
+				return loc
+			}
+		default:
 			return nil
 		}
+	}
+}
+
+// loadFailure is stored instead of locale which failed to load
+type loadFailure struct {
+	err      error
+	attempts int
+	retryAt  time.Time
+	loggedAt time.Time
+	retrying int32
+}
+
+func (t *Translator) tryLoadLocale(key localeKey, prev *loadFailure) interface{} {
+	loc, err := t.loadLocale(key)
+	if err == nil {
 		return loc
-	}).(Locale)
+	}
+	now := t.now()
+	res := &loadFailure{err: err}
+	if prev != nil {
+		res.attempts = prev.attempts + 1
+		res.loggedAt = prev.loggedAt
+	}
+	delay := t.retryMin
+	for i := 0; i < res.attempts && delay < t.retryMax; i++ {
+		delay *= 2
+	}
+	if delay > t.retryMax {
+		delay = t.retryMax
+	}
+	res.retryAt = now.Add(delay)
+	// repeated errors are logged not often than max retry delay
+	if prev == nil || prev.err.Error() != err.Error() || now.Sub(prev.loggedAt) >= t.retryMax {
+		res.loggedAt = now
+		if IsNotFound(err) {
+			t.logger.Printf("locale %q is not found: %v", key, err)
+		} else {
+			t.logger.Printf("error on load locale %q: %+v", key, err)
+		}
+	}
+
+	return res
+}
+
+func (t *Translator) loadLocale(key localeKey) (Locale, error) {
+	if t.watchInterval > 0 {
+		if ml, ok := t.loader.(ModTimeLoader); ok {
+			// modification time is taken before load to not miss changes
+			modTime, err := ml.ModTime(key.lang, key.domain)
+			if err == nil {
+				t.modTimes.Store(key, modTime)
+			}
+		}
+	}
 
-	return loc
+	return t.loader.Load(key.lang, key.domain)
 }
@@ -56,6 +56,32 @@ func (s *TranslatorSuite) TestPlural() {
 	s.Equal("22 страницы прочитаны.", msg)
 }
 
+func (s *TranslatorSuite) TestFallback() {
+	tr := pogo.NewTranslator("ru_RU", pogo.FileLoader(s.Pattern()))
+	ctx := context.Background()
+	s.Equal("Unknown message", tr.Translate(ctx, "Unknown message"))
+	n := 5
+	msg := tr.Translate(ctx, "%d file removed.",
+		pogo.WithPlural("%d files removed.", n), pogo.WithGoFormat(n),
+	)
+	s.Equal("5 files removed.", msg)
+}
+
+func (s *TranslatorSuite) TestFallbackChain() {
+	tr := pogo.NewTranslator("ru_RU",
+		pogo.FileLoader(s.Pattern()),
+		pogo.WithLogger(log.New(new(bytes.Buffer), "", 0)),
+		pogo.WithFallback("ru", "es_ES"),
+		pogo.WithFallback("", "ru"),
+	)
+	ctx := context.Background()
+	s.Equal("Hasta luego.", tr.Translate(ctx, "See you later."))
+	s.Equal("Сделаем интернет многоязычным.", tr.Translate(ctx, "Let’s make the web multilingual."))
+	ctx = pogo.ContextWithLanguage(ctx, "de_DE")
+	s.Equal("Сделаем интернет многоязычным.", tr.Translate(ctx, "Let’s make the web multilingual."))
+	s.Equal("See you later.", tr.Translate(ctx, "See you later."))
+}
+
 func (s *TranslatorSuite) TestLogger() {
 	buf := new(bytes.Buffer)
 	logger := log.New(buf, "", 0)
@@ -65,8 +91,10 @@ func (s *TranslatorSuite) TestLogger() {
 	ctx := context.Background()
 	msg := tr.Translate(ctx, "Let’s make the web multilingual.")
 	s.Equal("Let’s make the web multilingual.", msg)
-	s.Equal("error on load locale \"de_DE/default\":"+
-		" open testdata/locales/de/default.po: no such file or directory\n",
+	msg = tr.Translate(ctx, "Let’s make the web multilingual.")
+	s.Equal("Let’s make the web multilingual.", msg)
+	s.Equal("locale \"de_DE/default\" is not found:"+
+		" testdata/locales/de/default.{mo,po}: catalog not found\n",
 		buf.String())
 }
 
@@ -0,0 +1,73 @@
+package pogo
+
+import (
+	"fmt"
+)
+
+// ValidationError describe a problem of entry
+type ValidationError struct {
+	// Line of entry msgid in source, zero if unknown
+	Line    int
+	MsgID   string
+	Message string
+}
+
+// Error implements error
+func (err *ValidationError) Error() string {
+	if err.Line == 0 {
+		return fmt.Sprintf("msgid %q: %s", err.MsgID, err.Message)
+	}
+
+	return fmt.Sprintf("line %d: msgid %q: %s", err.Line, err.MsgID, err.Message)
+}
+
+// Validate entries of file
+//
+// Plural-Forms of header should be valid, it is replaced by rules of
+// language on reading otherwise. Plural forms of each not obsolete entry are
+// checked against Plural-Forms of header: missing and extra forms, forms of
+// entry without msgid_plural and msgid_plural without forms.
+func (po *POFile) Validate() []error {
+	var errs []error
+	report := func(entry *POEntry, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{
+			Line:    entry.Line,
+			MsgID:   entry.MsgID,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+	if po.Header.PluralFormsError != nil {
+		report(&POEntry{}, "invalid Plural-Forms: %s", po.Header.PluralFormsError)
+	}
+	hasPluralForms := po.PluralForms.expr != nil
+	n := po.PluralForms.Len()
+	for i := range po.Entries {
+		entry := &po.Entries[i]
+		if entry.Obsolete {
+			continue
+		}
+		if entry.MsgIDP == "" {
+			if len(entry.MsgStrP) > 0 {
+				report(entry, "plural forms without msgid_plural")
+			}
+			continue
+		}
+		switch {
+		case len(entry.MsgStrP) == 0:
+			report(entry, "msgid_plural without plural forms")
+		case !hasPluralForms:
+			report(entry, "plural forms are used, but header has no Plural-Forms")
+		case len(entry.MsgStrP) < n:
+			report(entry, "missed plural forms, expected %d, got %d", n, len(entry.MsgStrP))
+		case len(entry.MsgStrP) > n:
+			report(entry, "extra plural forms, expected %d, got %d", n, len(entry.MsgStrP))
+		}
+		for _, k := range entry.missedForms {
+			if k < len(entry.MsgStrP) && entry.MsgStrP[k] == "" {
+				report(entry, "missed plural form msgstr[%d]", k)
+			}
+		}
+	}
+
+	return errs
+}
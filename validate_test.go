@@ -0,0 +1,86 @@
+package pogo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+func TestPOFileValidate(t *testing.T) {
+	t.Parallel()
+
+	header := []string{
+		`msgid ""`, `msgstr ""`, `"Plural-Forms: nplurals=3; plural=n==1 ? 0 : n>=2 && n<=4 ? 1 : 2;\n"`, ``,
+	}
+	cases := [...]struct {
+		name   string
+		lines  []string
+		errors []string
+	}{
+		{
+			name: "valid",
+			lines: append(header,
+				`msgid "One"`, `msgstr "Jeden"`, ``,
+				`msgid "%d file"`, `msgid_plural "%d files"`,
+				`msgstr[0] "%d soubor"`, `msgstr[1] "%d soubory"`, `msgstr[2] "%d souborů"`, ``,
+				`#~ msgid "%d tab"`, `#~ msgid_plural "%d tabs"`, `#~ msgstr[0] "%d karta"`, ``,
+			),
+		},
+		{
+			name: "invalid",
+			lines: append(header,
+				`msgid "One"`, `msgstr[0] "Jeden"`, ``,
+				`msgid "%d file"`, `msgid_plural "%d files"`, `msgstr[0] "%d soubor"`, ``,
+				`msgid "%d tab"`, `msgid_plural "%d tabs"`,
+				`msgstr[0] "%d karta"`, `msgstr[1] "%d karty"`, `msgstr[2] "%d karet"`, `msgstr[3] "?"`, ``,
+				`msgid "%d day"`, `msgid_plural "%d days"`, `msgstr "%d den"`, ``,
+				`msgid "%d week"`, `msgid_plural "%d weeks"`, `msgstr[0] "%d týden"`, `msgstr[2] "%d týdnů"`, ``,
+			),
+			errors: []string{
+				`line 5: msgid "One": plural forms without msgid_plural`,
+				`line 8: msgid "%d file": missed plural forms, expected 3, got 1`,
+				`line 12: msgid "%d tab": extra plural forms, expected 3, got 4`,
+				`line 19: msgid "%d day": msgid_plural without plural forms`,
+				`line 23: msgid "%d week": missed plural form msgstr[1]`,
+			},
+		},
+		{
+			name: "invalid header",
+			lines: []string{
+				`msgid ""`, `msgstr ""`, `"Language: cs\n"`, `"Plural-Forms: nplurals=3; plural=n >;\n"`, ``,
+				`msgid "%d file"`, `msgid_plural "%d files"`,
+				`msgstr[0] "%d soubor"`, `msgstr[1] "%d soubory"`, `msgstr[2] "%d souborů"`,
+			},
+			errors: []string{
+				`msgid "": invalid Plural-Forms: 1:4: unexpected end of expression`,
+			},
+		},
+		{
+			name: "no header",
+			lines: []string{
+				`msgid "%d file"`, `msgid_plural "%d files"`, `msgstr[0] "%d file"`, `msgstr[1] "%d files"`,
+			},
+			errors: []string{
+				`line 1: msgid "%d file": plural forms are used, but header has no Plural-Forms`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			po, err := pogo.ReadPOFile(bytes.NewBufferString(strings.Join(c.lines, "\n")))
+			require.NoError(t, err)
+			var errs []string
+			for _, err := range po.Validate() {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, c.errors, errs)
+		})
+	}
+}
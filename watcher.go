@@ -0,0 +1,97 @@
+package pogo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ModTimeLoader is an optional interface of Loader to watch changes of catalogs
+type ModTimeLoader interface {
+	// ModTime returns last modification time of catalog sources
+	ModTime(lang, domain string) (time.Time, error)
+}
+
+// WithWatcher enable polling of catalogs changes until ctx is done
+//
+// Loader should implement ModTimeLoader (as FileLoader do), otherwise
+// watcher do nothing. Changed catalogs are reloaded in background and
+// replaced atomically.
+func WithWatcher(ctx context.Context, interval time.Duration) TranslatorOption {
+	return fnTranslatorOption(func(t *Translator) {
+		t.watchCtx = ctx
+		t.watchInterval = interval
+	})
+}
+
+// Reload catalog of language and domain
+//
+// New catalog replaces old one only after it is loaded completely. On error
+// old catalog is kept.
+func (t *Translator) Reload(lang, domain string) error {
+	key := localeKey{lang, domain}
+	loc, err := t.loadLocale(key)
+	if err != nil {
+		return errors.Wrapf(err, "reload locale %q", key)
+	}
+	t.locales.Store(key, loc)
+
+	return nil
+}
+
+// ReloadAll catalogs which have been loaded
+//
+// Returns first occurred error, but tries to reload all catalogs.
+func (t *Translator) ReloadAll() error {
+	var res error
+	for _, key := range t.locales.Keys() {
+		key := key.(localeKey)
+		if err := t.Reload(key.lang, key.domain); err != nil && res == nil {
+			res = err
+		}
+	}
+
+	return res
+}
+
+func (t *Translator) watch() {
+	ml, ok := t.loader.(ModTimeLoader)
+	if !ok {
+		return
+	}
+	ticks, stop := t.ticker(t.watchInterval)
+	defer stop()
+	for {
+		select {
+		case <-t.watchCtx.Done():
+			return
+		case <-ticks:
+			t.reloadModified(ml)
+		}
+	}
+}
+
+// newTicker returns channel of ticks and function to stop them
+func newTicker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+
+	return ticker.C, ticker.Stop
+}
+
+func (t *Translator) reloadModified(ml ModTimeLoader) {
+	for _, key := range t.locales.Keys() {
+		key := key.(localeKey)
+		modTime, err := ml.ModTime(key.lang, key.domain)
+		if err != nil {
+			t.logger.Printf("error on check locale %q: %+v", key, err)
+			continue
+		}
+		if prev, ok := t.modTimes.Load(key); ok && prev.(time.Time).Equal(modTime) {
+			continue
+		}
+		if err := t.Reload(key.lang, key.domain); err != nil {
+			t.logger.Printf("error on %+v", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package pogo_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vporoshok/pogo"
+)
+
+const watcherMsg = "Let’s make the web multilingual."
+
+func writeCatalog(t *testing.T, dir, msgstr string, modTime time.Time) {
+	name := filepath.Join(dir, "ru", "default.po")
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+	data := "msgid \"" + watcherMsg + "\"\nmsgstr \"" + msgstr + "\"\n"
+	require.NoError(t, ioutil.WriteFile(name, []byte(data), 0644))
+	require.NoError(t, os.Chtimes(name, modTime, modTime))
+}
+
+func TestTranslatorReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pogo")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	pattern := filepath.Join(dir, "{{ language }}", "{{ domain }}.{{ ext }}")
+	tr := pogo.NewTranslator("ru_RU", pogo.FileLoader(pattern),
+		pogo.WithLogger(log.New(new(bytes.Buffer), "", 0)))
+	ctx := context.Background()
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+
+	writeCatalog(t, dir, "Первая версия", time.Now())
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+	require.NoError(t, tr.Reload("ru_RU", "default"))
+	assert.Equal(t, "Первая версия", tr.Translate(ctx, watcherMsg))
+
+	writeCatalog(t, dir, "Вторая версия", time.Now())
+	require.NoError(t, tr.ReloadAll())
+	assert.Equal(t, "Вторая версия", tr.Translate(ctx, watcherMsg))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ru", "default.po"), []byte("msgid"), 0644))
+	assert.Error(t, tr.ReloadAll())
+	assert.Equal(t, "Вторая версия", tr.Translate(ctx, watcherMsg))
+}
+
+func TestTranslatorWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pogo")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	modTime := time.Now().Add(-time.Hour)
+	writeCatalog(t, dir, "Первая версия", modTime)
+	pattern := filepath.Join(dir, "{{ language }}", "{{ domain }}.{{ ext }}")
+	ticks := make(chan time.Time)
+	tr := pogo.NewTranslator("ru_RU", pogo.FileLoader(pattern),
+		pogo.WithLogger(log.New(new(bytes.Buffer), "", 0)),
+		pogo.WithWatcher(ctx, time.Hour), pogo.WithTicks(ticks))
+	// tick is received only after previous one is handled
+	tick := func() {
+		ticks <- time.Now()
+		ticks <- time.Now()
+	}
+	assert.Equal(t, "Первая версия", tr.Translate(ctx, watcherMsg))
+	tick()
+	assert.Equal(t, "Первая версия", tr.Translate(ctx, watcherMsg))
+
+	writeCatalog(t, dir, "Вторая версия", modTime.Add(time.Minute))
+	assert.Equal(t, "Первая версия", tr.Translate(ctx, watcherMsg))
+	tick()
+	assert.Equal(t, "Вторая версия", tr.Translate(ctx, watcherMsg))
+}
+
+func TestTranslatorRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pogo")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	buf := new(bytes.Buffer)
+	now := time.Now()
+	pattern := filepath.Join(dir, "{{ language }}", "{{ domain }}.{{ ext }}")
+	tr := pogo.NewTranslator("ru", pogo.FileLoader(pattern),
+		pogo.WithLogger(log.New(buf, "", 0)),
+		pogo.WithRetry(10*time.Millisecond, time.Hour),
+		pogo.WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+
+	name := filepath.Join(dir, "ru", "default.po")
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+	require.NoError(t, ioutil.WriteFile(name, []byte("msgid"), 0644))
+	// not retried before delay
+	now = now.Add(5 * time.Millisecond)
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+	assert.Equal(t, 0, strings.Count(buf.String(), `error on load locale "ru/default"`), buf.String())
+	now = now.Add(15 * time.Millisecond)
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+	now = now.Add(40 * time.Millisecond)
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+
+	writeCatalog(t, dir, "Перевод", time.Now())
+	// delay is doubled on each attempt
+	now = now.Add(20 * time.Millisecond)
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+	now = now.Add(60 * time.Millisecond)
+	assert.Equal(t, "Перевод", tr.Translate(ctx, watcherMsg))
+
+	assert.True(t, strings.HasPrefix(buf.String(), `locale "ru/default" is not found: `), buf.String())
+	assert.Equal(t, 1, strings.Count(buf.String(), `is not found`), buf.String())
+	assert.Equal(t, 1, strings.Count(buf.String(), `error on load locale "ru/default"`), buf.String())
+	assert.Equal(t, 1, strings.Count(buf.String(), `parse "`), buf.String())
+}
+
+func TestTranslatorNoRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pogo")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	pattern := filepath.Join(dir, "{{ language }}", "{{ domain }}.{{ ext }}")
+	tr := pogo.NewTranslator("ru", pogo.FileLoader(pattern),
+		pogo.WithLogger(log.New(new(bytes.Buffer), "", 0)),
+		pogo.WithRetry(0, 0))
+	ctx := context.Background()
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+	writeCatalog(t, dir, "Перевод", time.Now())
+	assert.Equal(t, watcherMsg, tr.Translate(ctx, watcherMsg))
+}